@@ -41,6 +41,8 @@ func (d *Data) Balance(ctx echo.Context) error {
 		return apiError(balancesRetrieval, err)
 	}
 
+	immutable(ctx, req.BlockID)
+
 	res := response.Balance{
 		BlockID:  rosBlockID,
 		Balances: balances,
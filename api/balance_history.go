@@ -0,0 +1,55 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+// BalanceHistory implements the non-standard /account/balance/history endpoint.
+// It returns the balance of an account for a currency at every block of a range
+// where it changed.
+func (d *Data) BalanceHistory(ctx echo.Context) error {
+
+	var req request.BalanceHistory
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	err = d.validate.Request(req)
+	if err != nil {
+		return formatError(err)
+	}
+
+	balances, err := d.retrieve.BalanceHistory(req.AccountID, req.Currency, req.StartBlockID, req.EndBlockID)
+	if err != nil {
+		return apiError(balancesRetrieval, err)
+	}
+
+	// The history only stays the same if both ends of the range are fixed.
+	immutable(ctx, req.StartBlockID, req.EndBlockID)
+
+	res := response.BalanceHistory{
+		AccountID: req.AccountID,
+		Currency:  req.Currency,
+		Balances:  balances,
+	}
+
+	return ctx.JSON(statusOK, res)
+}
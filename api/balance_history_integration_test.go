@@ -0,0 +1,172 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+func TestAPI_BalanceHistory(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	const testAccount = "10c4fef62310c807"
+
+	var (
+		zeroBlock = knownHeader(1)   // block before the account appears
+		lastBlock = knownHeader(173) // last indexed block
+	)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		req := requestHistory(testAccount, zeroBlock.Height, lastBlock.Height)
+
+		rec, ctx, err := setupRecorder(historyEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BalanceHistory(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		assert.Equal(t, "public, max-age=31536000, immutable", rec.Result().Header.Get("Cache-Control"))
+
+		var res response.BalanceHistory
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+		assert.Equal(t, req.AccountID, res.AccountID)
+		assert.Equal(t, req.Currency, res.Currency)
+
+		// The account first receives tokens at height 41, and its balance changes
+		// for the last time at height 164.
+		require.Len(t, res.Balances, 18)
+
+		first := res.Balances[0]
+		validateByHeader(t, zeroBlock)(first.BlockID)
+		assert.Equal(t, "0", first.Value)
+
+		second := res.Balances[1]
+		validateByHeader(t, knownHeader(41))(second.BlockID)
+		assert.Equal(t, "100000100000", second.Value)
+
+		last := res.Balances[len(res.Balances)-1]
+		validateByHeader(t, knownHeader(164))(last.BlockID)
+		assert.Equal(t, "104000100000", last.Value)
+
+		for i := 1; i < len(res.Balances); i++ {
+			assert.NotEqual(t, res.Balances[i-1].Value, res.Balances[i].Value)
+		}
+	})
+
+	t.Run("start block identified by timestamp", func(t *testing.T) {
+		t.Parallel()
+
+		// The start of the range can resolve to a later block as new blocks are
+		// indexed, so the response is not immutable even though the end is fixed.
+		timestamp := zeroBlock.Timestamp.UnixNano() / 1_000_000
+		req := requestHistory(testAccount, zeroBlock.Height, lastBlock.Height)
+		req.StartBlockID = identifier.Block{Timestamp: &timestamp}
+
+		rec, ctx, err := setupRecorder(historyEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BalanceHistory(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		assert.Empty(t, rec.Result().Header.Get("Cache-Control"))
+
+		var res response.BalanceHistory
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.NotEmpty(t, res.Balances)
+	})
+
+	t.Run("start block is latest block", func(t *testing.T) {
+		t.Parallel()
+
+		req := requestHistory(testAccount, lastBlock.Height, lastBlock.Height)
+		req.StartBlockID = identifier.Block{}
+
+		rec, ctx, err := setupRecorder(historyEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BalanceHistory(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		assert.Empty(t, rec.Result().Header.Get("Cache-Control"))
+	})
+
+	t.Run("handles inverted block range", func(t *testing.T) {
+		t.Parallel()
+
+		req := requestHistory(testAccount, lastBlock.Height, zeroBlock.Height)
+
+		_, ctx, err := setupRecorder(historyEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BalanceHistory(ctx)
+		checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidBlock)(t, err)
+	})
+
+	t.Run("handles missing currency symbol", func(t *testing.T) {
+		t.Parallel()
+
+		req := requestHistory(testAccount, zeroBlock.Height, lastBlock.Height)
+		req.Currency = identifier.Currency{}
+
+		_, ctx, err := setupRecorder(historyEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BalanceHistory(ctx)
+		checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat)(t, err)
+	})
+
+	t.Run("handles unknown end block", func(t *testing.T) {
+		t.Parallel()
+
+		req := requestHistory(testAccount, zeroBlock.Height, lastBlock.Height+1)
+
+		_, ctx, err := setupRecorder(historyEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BalanceHistory(ctx)
+		checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorUnknownBlock)(t, err)
+	})
+}
+
+func requestHistory(address string, start uint64, end uint64) request.BalanceHistory {
+	return request.BalanceHistory{
+		NetworkID:    defaultNetwork(),
+		AccountID:    identifier.Account{Address: address},
+		Currency:     defaultCurrency()[0],
+		StartBlockID: identifier.Block{Index: &start},
+		EndBlockID:   identifier.Block{Index: &end},
+	}
+}
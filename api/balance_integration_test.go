@@ -19,6 +19,7 @@ package api_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -28,6 +29,7 @@ import (
 
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps/models/convert"
 	"github.com/optakt/flow-dps/models/dps"
 
 	"github.com/optakt/flow-dps-rosetta/api"
@@ -100,6 +102,48 @@ func TestAPI_Balance(t *testing.T) {
 			wantBalance:   "102000100000",
 			validateBlock: validateBlock(t, secondBlock.Height, secondBlock.ID().String()),
 		},
+		{
+			name:          "locked sub-account",
+			request:       requestSubBalance(testAccount, configuration.SubAccountLocked, lastBlock),
+			wantBalance:   "0",
+			validateBlock: validateBlock(t, lastBlock.Height, lastBlock.ID().String()),
+		},
+		{
+			name:          "staked sub-account",
+			request:       requestSubBalance(testAccount, configuration.SubAccountStaked, lastBlock),
+			wantBalance:   "0",
+			validateBlock: validateBlock(t, lastBlock.Height, lastBlock.ID().String()),
+		},
+		{
+			name:          "delegated sub-account",
+			request:       requestSubBalance(testAccount, configuration.SubAccountDelegated, lastBlock),
+			wantBalance:   "0",
+			validateBlock: validateBlock(t, lastBlock.Height, lastBlock.ID().String()),
+		},
+		{
+			// The minimum storage reservation on localnet is 0.001 FLOW.
+			name:          "storage reserved sub-account",
+			request:       requestSubBalance(testAccount, configuration.SubAccountStorageReserved, lastBlock),
+			wantBalance:   "100000",
+			validateBlock: validateBlock(t, lastBlock.Height, lastBlock.ID().String()),
+		},
+		{
+			// Use block timestamp only to retrieve data, but verify height and hash are set in the response.
+			name: "get block via timestamp only",
+			request: request.Balance{
+				NetworkID: defaultNetwork(),
+				AccountID: identifier.Account{
+					Address: testAccount,
+				},
+				BlockID: identifier.Block{
+					Timestamp: getInt64P(convert.RosettaTime(secondBlock.Timestamp)),
+				},
+				Currencies: defaultCurrency(),
+			},
+
+			wantBalance:   "102000100000",
+			validateBlock: validateBlock(t, secondBlock.Height, secondBlock.ID().String()),
+		},
 		{
 			name: "get latest block by omitting block identifier",
 			request: request.Balance{
@@ -146,6 +190,107 @@ func TestAPI_Balance(t *testing.T) {
 	}
 }
 
+func TestAPI_BalanceCacheHeaders(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	const testAccount = "10c4fef62310c807"
+	header := knownHeader(116)
+	timestamp := header.Timestamp.UnixNano() / 1_000_000
+
+	tests := []struct {
+		name string
+
+		blockID identifier.Block
+
+		wantControl string
+	}{
+		{
+			name: "block index and hash",
+			blockID: identifier.Block{
+				Index: &header.Height,
+				Hash:  header.ID().String(),
+			},
+			wantControl: "public, max-age=31536000, immutable",
+		},
+		{
+			name: "block hash only",
+			blockID: identifier.Block{
+				Hash: header.ID().String(),
+			},
+			wantControl: "public, max-age=31536000, immutable",
+		},
+		{
+			name:        "latest block",
+			blockID:     identifier.Block{},
+			wantControl: "",
+		},
+		{
+			name: "block timestamp",
+			blockID: identifier.Block{
+				Timestamp: &timestamp,
+			},
+			wantControl: "",
+		},
+	}
+
+	for _, test := range tests {
+
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			req := requestBalance(testAccount, header)
+			req.BlockID = test.blockID
+
+			rec, ctx, err := setupRecorder(balanceEndpoint, req)
+			require.NoError(t, err)
+
+			err = data.Balance(ctx)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+			assert.Equal(t, test.wantControl, rec.Result().Header.Get("Cache-Control"))
+		})
+	}
+}
+
+func TestAPI_BalanceMatchesScripts(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+	scripts := setupScriptAPI(t, db)
+
+	accounts := []string{
+		"f8d6e0586b0a20c7", // service account
+		"10c4fef62310c807",
+		"e5a8b7f23e8b548f",
+		"0ae53cb6e3f42a79",
+	}
+	heights := []uint64{0, 1, 41, 47, 116, 164, 173}
+
+	for _, account := range accounts {
+		for _, height := range heights {
+
+			account := account
+			header := knownHeader(height)
+			t.Run(fmt.Sprintf("%s at height %d", account, height), func(t *testing.T) {
+
+				t.Parallel()
+
+				req := requestBalance(account, header)
+
+				want := balanceValue(t, scripts, req)
+				got := balanceValue(t, data, req)
+
+				assert.Equal(t, want, got)
+			})
+		}
+	}
+}
+
 func TestAPI_BalanceHandlesErrors(t *testing.T) {
 
 	db := setupDB(t)
@@ -378,6 +523,20 @@ func TestAPI_BalanceHandlesErrors(t *testing.T) {
 
 			checkError: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidAccount),
 		},
+		{
+			name: "unknown sub-account",
+			request: request.Balance{
+				NetworkID: defaultNetwork(),
+				AccountID: identifier.Account{
+					Address:    testAccount.Address,
+					SubAccount: &identifier.SubAccount{Address: "unknown"},
+				},
+				BlockID:    testBlock,
+				Currencies: defaultCurrency(),
+			},
+
+			checkError: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidAccount),
+		},
 		{
 			name: "unknown currency requested",
 			request: request.Balance{
@@ -551,3 +710,30 @@ func requestBalance(address string, header flow.Header) request.Balance {
 		Currencies: defaultCurrency(),
 	}
 }
+
+// balanceValue returns the value of the single balance returned by the given
+// Data API for the given request.
+func balanceValue(t *testing.T, data *api.Data, req request.Balance) string {
+	t.Helper()
+
+	rec, ctx, err := setupRecorder(balanceEndpoint, req)
+	require.NoError(t, err)
+
+	err = data.Balance(ctx)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+	var balanceResponse response.Balance
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &balanceResponse))
+	require.Len(t, balanceResponse.Balances, 1)
+
+	return balanceResponse.Balances[0].Value
+}
+
+func requestSubBalance(address string, subAccount string, header flow.Header) request.Balance {
+
+	req := requestBalance(address, header)
+	req.AccountID.SubAccount = &identifier.SubAccount{Address: subAccount}
+
+	return req
+}
@@ -41,6 +41,8 @@ func (d *Data) Block(ctx echo.Context) error {
 		return apiError(blockRetrieval, err)
 	}
 
+	immutable(ctx, req.BlockID)
+
 	res := response.Block{
 		Block:             block,
 		OtherTransactions: extraTxIDs,
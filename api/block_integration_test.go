@@ -142,6 +142,45 @@ func TestAPI_Block(t *testing.T) {
 			validateTransactions: validateTransfer(t, secondTx, senderAccount, senderReceiverAccount, 5_00000000),
 			validateBlock:        validateBlock(t, midHeader3.Height, midHeader3.ID().String()), // verify that the returned block ID has both height and hash
 		},
+		{
+			name: "lookup of a block mid-chain by its exact timestamp",
+			request: request.Block{
+				NetworkID: defaultNetwork(),
+				BlockID:   identifier.Block{Timestamp: getInt64P(convert.RosettaTime(midHeader3.Timestamp))},
+			},
+
+			wantTimestamp:        convert.RosettaTime(midHeader3.Timestamp),
+			wantParentHash:       midHeader3.ParentID.String(),
+			wantParentHeight:     midHeader3.Height - 1,
+			validateTransactions: validateTransfer(t, secondTx, senderAccount, senderReceiverAccount, 5_00000000),
+			validateBlock:        validateByHeader(t, midHeader3),
+		},
+		{
+			// A timestamp between two blocks resolves to the earlier one.
+			name: "lookup of a block mid-chain by a later timestamp",
+			request: request.Block{
+				NetworkID: defaultNetwork(),
+				BlockID:   identifier.Block{Timestamp: getInt64P(convert.RosettaTime(midHeader3.Timestamp) + 1)},
+			},
+
+			wantTimestamp:        convert.RosettaTime(midHeader3.Timestamp),
+			wantParentHash:       midHeader3.ParentID.String(),
+			wantParentHeight:     midHeader3.Height - 1,
+			validateTransactions: validateTransfer(t, secondTx, senderAccount, senderReceiverAccount, 5_00000000),
+			validateBlock:        validateByHeader(t, midHeader3),
+		},
+		{
+			name: "lookup of the last indexed block by a timestamp after it",
+			request: request.Block{
+				NetworkID: defaultNetwork(),
+				BlockID:   identifier.Block{Timestamp: getInt64P(convert.RosettaTime(lastHeader.Timestamp) + 60_000)},
+			},
+
+			wantTimestamp:    convert.RosettaTime(lastHeader.Timestamp),
+			wantParentHash:   lastHeader.ParentID.String(),
+			wantParentHeight: lastHeader.Height - 1,
+			validateBlock:    validateByHeader(t, lastHeader),
+		},
 		{
 			name:    "last indexed block",
 			request: blockRequest(lastHeader),
@@ -315,6 +354,29 @@ func TestAPI_BlockHandlesErrors(t *testing.T) {
 
 			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorUnknownBlock),
 		},
+		{
+			name: "timestamp before first block",
+			request: request.Block{
+				NetworkID: defaultNetwork(),
+				BlockID: identifier.Block{
+					Timestamp: getInt64P(convert.RosettaTime(knownHeader(0).Timestamp) - 1),
+				},
+			},
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidBlock),
+		},
+		{
+			name: "timestamp together with block height",
+			request: request.Block{
+				NetworkID: defaultNetwork(),
+				BlockID: identifier.Block{
+					Index:     &validBlockHeight,
+					Timestamp: getInt64P(convert.RosettaTime(knownHeader(validBlockHeight).Timestamp)),
+				},
+			},
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidBlock),
+		},
 		{
 			name: "mismatched block height and hash",
 			request: request.Block{
@@ -505,5 +567,11 @@ func validateTransfer(t *testing.T, hash string, from string, to string, amount
 		}
 
 		assert.Equal(t, op2.Amount.Value, wantValue)
+
+		// The withdrawal and the deposit are related, with the later operation
+		// referencing the earlier one.
+		assert.Empty(t, op1.RelatedIDs)
+		require.Len(t, op2.RelatedIDs, 1)
+		assert.Equal(t, op1.ID.Index, op2.RelatedIDs[0].Index)
 	}
 }
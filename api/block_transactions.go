@@ -0,0 +1,57 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+// BlockTransactions implements the non-standard /block/transactions endpoint. It
+// returns a page of fully populated transactions of a block, so that clients can
+// retrieve the transactions listed as other transactions on the /block endpoint
+// without requesting each of them separately.
+func (d *Data) BlockTransactions(ctx echo.Context) error {
+
+	var req request.BlockTransactions
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	err = d.validate.Request(req)
+	if err != nil {
+		return formatError(err)
+	}
+
+	rosBlockID, transactions, next, err := d.retrieve.BlockTransactions(req.BlockID, req.Offset, req.Limit)
+	if err != nil {
+		return apiError(blockRetrieval, err)
+	}
+
+	immutable(ctx, req.BlockID)
+
+	res := response.BlockTransactions{
+		BlockID:      rosBlockID,
+		Transactions: transactions,
+	}
+	if next != 0 {
+		res.NextOffset = &next
+	}
+
+	return ctx.JSON(statusOK, res)
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+func TestAPI_BlockTransactions(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	header := knownHeader(47)
+
+	const (
+		senderReceiverAccount = "e2f72218abeec2b9"
+		receiverAccount       = "06909bc5ba14c266"
+
+		firstTx = "2d394a7841c91c5470e6e3cabb1e7ed57609ef41117bba84ced01d37659f2861"
+	)
+
+	t.Run("nominal case with single page", func(t *testing.T) {
+		t.Parallel()
+
+		req := request.BlockTransactions{
+			NetworkID: defaultNetwork(),
+			BlockID:   identifier.Block{Index: &header.Height},
+			Offset:    0,
+			Limit:     1,
+		}
+
+		rec, ctx, err := setupRecorder(transactionsEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BlockTransactions(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var res response.BlockTransactions
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+		validateByHeader(t, header)(res.BlockID)
+		validateTransfer(t, firstTx, senderReceiverAccount, receiverAccount, 5_00000000)(res.Transactions)
+		assert.Nil(t, res.NextOffset)
+	})
+
+	t.Run("nominal case with offset past last transaction", func(t *testing.T) {
+		t.Parallel()
+
+		req := request.BlockTransactions{
+			NetworkID: defaultNetwork(),
+			BlockID:   identifier.Block{Index: &header.Height},
+			Offset:    1,
+		}
+
+		rec, ctx, err := setupRecorder(transactionsEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BlockTransactions(ctx)
+		require.NoError(t, err)
+
+		var res response.BlockTransactions
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+		validateByHeader(t, header)(res.BlockID)
+		assert.Empty(t, res.Transactions)
+		assert.Nil(t, res.NextOffset)
+	})
+
+	t.Run("handles unknown block", func(t *testing.T) {
+		t.Parallel()
+
+		req := request.BlockTransactions{
+			NetworkID: defaultNetwork(),
+			BlockID:   identifier.Block{Index: getUint64P(174)},
+		}
+
+		_, ctx, err := setupRecorder(transactionsEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BlockTransactions(ctx)
+		checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorUnknownBlock)(t, err)
+	})
+
+	t.Run("handles invalid network", func(t *testing.T) {
+		t.Parallel()
+
+		req := request.BlockTransactions{
+			NetworkID: identifier.Network{
+				Blockchain: invalidBlockchain,
+				Network:    defaultNetwork().Network,
+			},
+			BlockID: identifier.Block{Index: &header.Height},
+		}
+
+		_, ctx, err := setupRecorder(transactionsEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.BlockTransactions(ctx)
+		checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork)(t, err)
+	})
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// The response for a block identified by index or hash never changes, because only
+// sealed blocks are indexed, so it can be cached by clients and proxies for as long
+// as they want. Responses for the latest block, or a block identified by timestamp,
+// change as new blocks are indexed, and are thus never marked as immutable.
+const (
+	headerCacheControl = "Cache-Control"
+	immutableControl   = "public, max-age=31536000, immutable"
+)
+
+// immutable sets the HTTP caching headers of the response if it refers to the given
+// block identifiers, and each of them references a specific block. Identifiers with
+// a timestamp are always skipped, as the block they resolve to can change.
+func immutable(ctx echo.Context, rosBlockIDs ...identifier.Block) {
+	for _, rosBlockID := range rosBlockIDs {
+		if rosBlockID.Timestamp != nil {
+			return
+		}
+		if rosBlockID.Index == nil && rosBlockID.Hash == "" {
+			return
+		}
+	}
+	ctx.Response().Header().Set(headerCacheControl, immutableControl)
+}
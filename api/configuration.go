@@ -29,4 +29,5 @@ type Configuration interface {
 	Operations() []string
 	Statuses() []meta.StatusDefinition
 	Errors() []meta.ErrorDefinition
+	Exemptions() []meta.BalanceExemption
 }
@@ -20,15 +20,24 @@ type Data struct {
 	config   Configuration
 	retrieve Retriever
 	validate Validator
+	track    Tracker
+	pool     Pool
+	events   Events
+	search   Search
 }
 
-// NewData creates a new instance of the Data API using the given configuration to answer configuration queries
-// and the given retriever to answer blockchain data queries.
-func NewData(config Configuration, retrieve Retriever, validate Validator) *Data {
+// NewData creates a new instance of the Data API using the given configuration to answer configuration queries,
+// the given retriever to answer blockchain data queries, the given pool to answer mempool queries, the given
+// event log to answer block event queries and the given search index to answer transaction search queries.
+func NewData(config Configuration, retrieve Retriever, validate Validator, track Tracker, pool Pool, events Events, search Search) *Data {
 	d := Data{
 		config:   config,
 		retrieve: retrieve,
 		validate: validate,
+		track:    track,
+		pool:     pool,
+		events:   events,
+		search:   search,
 	}
 	return &d
 }
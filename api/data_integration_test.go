@@ -19,6 +19,7 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -34,17 +35,27 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 
+	sdk "github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go/model/flow"
 
 	rosetta "github.com/optakt/flow-dps-rosetta/api"
+	"github.com/optakt/flow-dps-rosetta/service/cache"
 	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/converter"
+	"github.com/optakt/flow-dps-rosetta/service/events"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/service/meta"
 	"github.com/optakt/flow-dps-rosetta/service/retriever"
 	"github.com/optakt/flow-dps-rosetta/service/scripts"
+	"github.com/optakt/flow-dps-rosetta/service/search"
+	"github.com/optakt/flow-dps-rosetta/service/submitter"
+	"github.com/optakt/flow-dps-rosetta/service/tracker"
+	"github.com/optakt/flow-dps-rosetta/service/transactor"
 	"github.com/optakt/flow-dps-rosetta/service/validator"
+	"github.com/optakt/flow-dps-rosetta/service/vault"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
 	"github.com/optakt/flow-dps-rosetta/testing/snapshots"
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/dps"
@@ -54,17 +65,25 @@ import (
 )
 
 const (
-	balanceEndpoint     = "/account/balance"
-	blockEndpoint       = "/block"
-	transactionEndpoint = "/block/transaction"
-	listEndpoint        = "/network/list"
-	optionsEndpoint     = "/network/options"
-	statusEndpoint      = "/network/status"
+	balanceEndpoint      = "/account/balance"
+	historyEndpoint      = "/account/balance/history"
+	blockEndpoint        = "/block"
+	transactionEndpoint  = "/block/transaction"
+	transactionsEndpoint = "/block/transactions"
+	listEndpoint         = "/network/list"
+	optionsEndpoint      = "/network/options"
+	statusEndpoint       = "/network/status"
+	mempoolEndpoint      = "/mempool"
+	mempoolTxEndpoint    = "/mempool/transaction"
+	eventsEndpoint       = "/events/blocks"
+	searchEndpoint       = "/search/transactions"
 
 	invalidBlockchain = "invalid-blockchain"
 	invalidNetwork    = "invalid-network"
 	invalidToken      = "invalid-token"
 
+	accessLag = 7
+
 	invalidBlockHash = "f91704ce2fa9a1513500184ebfec884a1728438463c0104f8a17d5c66dd1af7z" // invalid hex value
 )
 
@@ -94,26 +113,124 @@ func setupDB(t *testing.T) *badger.DB {
 func setupAPI(t *testing.T, db *badger.DB) *rosetta.Data {
 	t.Helper()
 
-	rosetta.EnableSmartCodes()
+	codec := zbor.NewCodec()
+	storage := storage.New(codec)
+	index := index.NewReader(db, storage)
+	params := dps.FlowParams[dps.FlowLocalnet]
+
+	access := setupAccess(t, index)
+
+	return setupData(t, index, vault.New(params, index), access, submitter.New(access))
+}
+
+// setupScriptAPI returns a Data API that never reads balances from storage
+// registers, so that all balances are retrieved by executing scripts.
+func setupScriptAPI(t *testing.T, db *badger.DB) *rosetta.Data {
+	t.Helper()
 
 	codec := zbor.NewCodec()
 	storage := storage.New(codec)
 	index := index.NewReader(db, storage)
 
+	access := setupAccess(t, index)
+
+	return setupData(t, index, mocks.BaselineVault(t), access, submitter.New(access))
+}
+
+// setupStatusAPI returns a Data API that tracks the synchronization of the
+// index with the given Access API mock.
+func setupStatusAPI(t *testing.T, db *badger.DB, access *mocks.AccessAPI) *rosetta.Data {
+	t.Helper()
+
+	codec := zbor.NewCodec()
+	storage := storage.New(codec)
+	index := index.NewReader(db, storage)
+	params := dps.FlowParams[dps.FlowLocalnet]
+
+	return setupData(t, index, vault.New(params, index), access, submitter.New(access))
+}
+
+// setupMempoolAPI returns a Data API along with the submitter that backs its
+// mempool, which looks up the status of submitted transactions with the given
+// Access API mock.
+func setupMempoolAPI(t *testing.T, db *badger.DB, access *mocks.AccessAPI) (*rosetta.Data, *submitter.Submitter) {
+	t.Helper()
+
+	codec := zbor.NewCodec()
+	storage := storage.New(codec)
+	index := index.NewReader(db, storage)
+	params := dps.FlowParams[dps.FlowLocalnet]
+	submit := submitter.New(access)
+
+	return setupData(t, index, vault.New(params, index), setupAccess(t, index), submit), submit
+}
+
+func setupData(t *testing.T, index dps.Reader, read retriever.Vault, access *mocks.AccessAPI, submit transactor.Submitter) *rosetta.Data {
+	t.Helper()
+
+	rosetta.EnableSmartCodes()
+
 	params := dps.FlowParams[dps.FlowLocalnet]
 	config := configuration.New(params.ChainID)
 	validate := validator.New(params, index, config)
 	generate := scripts.NewGenerator(params)
 	invoke, err := invoker.New(index)
 	require.NoError(t, err)
-	convert, err := converter.New(generate)
+	convert, err := converter.New(params, generate)
+	require.NoError(t, err)
+	responses, err := cache.New()
+	require.NoError(t, err)
+	retrieve := retriever.New(params, index, validate, generate, invoke, read, convert, responses)
+	track := tracker.New(index, access)
+	transact := transactor.New(validate, generate, invoke, submit)
+	follow, err := events.New(setupMemoryDB(t), index)
+	require.NoError(t, err)
+	err = follow.Update(context.Background())
+	require.NoError(t, err)
+	find, err := search.New(setupMemoryDB(t), index, validate, retrieve)
 	require.NoError(t, err)
-	retrieve := retriever.New(params, index, validate, generate, invoke, convert)
-	controller := rosetta.NewData(config, retrieve, validate)
+	err = find.Update(context.Background())
+	require.NoError(t, err)
+	controller := rosetta.NewData(config, retrieve, validate, track, transact, follow, find)
 
 	return controller
 }
 
+// setupMemoryDB returns an in-memory database for the block event log or the
+// search index, which is closed at the end of the test.
+func setupMemoryDB(t *testing.T) *badger.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").
+		WithInMemory(true).
+		WithLogger(nil)
+
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+// setupAccess returns an Access API mock for which the latest sealed block is
+// a few blocks after the last indexed block, within the default sync tolerance.
+func setupAccess(t *testing.T, index dps.Reader) *mocks.AccessAPI {
+	t.Helper()
+
+	last, err := index.Last()
+	require.NoError(t, err)
+
+	access := mocks.BaselineAccessAPI(t)
+	access.GetLatestBlockHeaderFunc = func(context.Context, bool, ...grpc.CallOption) (*sdk.BlockHeader, error) {
+		return &sdk.BlockHeader{Height: last + accessLag}, nil
+	}
+
+	return access
+}
+
 func setupRecorder(endpoint string, input interface{}, options ...func(*http.Request)) (*httptest.ResponseRecorder, echo.Context, error) {
 
 	payload, ok := input.([]byte)
@@ -197,10 +314,22 @@ func validateByHeader(t *testing.T, header flow.Header) validateBlockFunc {
 	return validateBlock(t, header.Height, header.ID().String())
 }
 
+func getInt64P(n int64) *int64 {
+	return &n
+}
+
 func getUint64P(n uint64) *uint64 {
 	return &n
 }
 
+func getUintP(n uint) *uint {
+	return &n
+}
+
+func getBoolP(b bool) *bool {
+	return &b
+}
+
 func knownHeader(height uint64) flow.Header {
 
 	switch height {
@@ -34,9 +34,13 @@ const (
 	blockRetrieval          = "unable to retrieve block"
 	balancesRetrieval       = "unable to retrieve balances"
 	oldestRetrieval         = "unable to retrieve oldest block"
+	genesisRetrieval        = "unable to retrieve genesis block"
 	currentRetrieval        = "unable to retrieve current block"
 	txSubmission            = "unable to submit transaction"
 	txRetrieval             = "unable to retrieve transaction"
+	mempoolRetrieval        = "unable to retrieve mempool transactions"
+	eventsRetrieval         = "unable to retrieve block events"
+	searchRetrieval         = "unable to search transactions"
 	intentDetermination     = "unable to determine transaction intent"
 	referenceBlockRetrieval = "unable to retrieve transaction reference block"
 	sequenceNumberRetrieval = "unable to retrieve account key sequence number"
@@ -45,6 +49,7 @@ const (
 	txSigning               = "unable to sign transaction"
 	payloadHashing          = "unable to hash signing payload"
 	txIdentifier            = "unable to retrieve transaction identifier"
+	networkRouting          = "unable to route request to network"
 )
 
 // Error represents an error as defined by the Rosetta API specification. It
@@ -0,0 +1,51 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+// EventsBlocks implements the /events/blocks endpoint of the Rosetta Data API.
+// It returns a page of the block event log, starting at the given offset.
+// See https://www.rosetta-api.org/docs/EventsApi.html#eventsblocks
+func (d *Data) EventsBlocks(ctx echo.Context) error {
+
+	var req request.EventsBlocks
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	err = d.validate.Request(req)
+	if err != nil {
+		return formatError(err)
+	}
+
+	events, max, err := d.events.Blocks(req.Offset, req.Limit)
+	if err != nil {
+		return apiError(eventsRetrieval, err)
+	}
+
+	res := response.EventsBlocks{
+		MaxSequence: max,
+		Events:      events,
+	}
+
+	return ctx.JSON(statusOK, res)
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/events"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+func TestAPI_EventsBlocks(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	const lastHeight = 173
+
+	tests := []struct {
+		name string
+
+		request request.EventsBlocks
+
+		wantSequences []uint64
+	}{
+		{
+			name:          "first page",
+			request:       requestEvents(0, 3),
+			wantSequences: []uint64{0, 1, 2},
+		},
+		{
+			name:          "page with known blocks",
+			request:       requestEvents(41, 7),
+			wantSequences: []uint64{41, 42, 43, 44, 45, 46, 47},
+		},
+		{
+			name:          "last page",
+			request:       requestEvents(172, 10),
+			wantSequences: []uint64{172, 173},
+		},
+		{
+			name:          "beyond last event",
+			request:       requestEvents(lastHeight+1, 10),
+			wantSequences: []uint64{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			rec, ctx, err := setupRecorder(eventsEndpoint, test.request)
+			require.NoError(t, err)
+
+			err = data.EventsBlocks(ctx)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			var res response.EventsBlocks
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+			// The snapshot index starts at the root block, so the sequence
+			// number of each event matches the height of its block.
+			assert.Equal(t, uint64(lastHeight), res.MaxSequence)
+			require.Len(t, res.Events, len(test.wantSequences))
+			for i, event := range res.Events {
+				assert.Equal(t, test.wantSequences[i], event.Sequence)
+				assert.Equal(t, events.TypeBlockAdded, event.Type)
+				require.NotNil(t, event.BlockID.Index)
+				assert.Equal(t, event.Sequence, *event.BlockID.Index)
+				assert.Len(t, event.BlockID.Hash, 64)
+			}
+		})
+	}
+
+	t.Run("block identifiers match blocks", func(t *testing.T) {
+
+		rec, ctx, err := setupRecorder(eventsEndpoint, requestEvents(0, 0))
+		require.NoError(t, err)
+
+		err = data.EventsBlocks(ctx)
+		require.NoError(t, err)
+
+		var res response.EventsBlocks
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Events, lastHeight+1)
+
+		for _, height := range []uint64{0, 1, 41, 47, 57, 60, 65, 116, 164, 173} {
+			header := knownHeader(height)
+			event := res.Events[height]
+			assert.Equal(t, header.Height, *event.BlockID.Index)
+			assert.Equal(t, header.ID().String(), event.BlockID.Hash)
+		}
+	})
+}
+
+func TestAPI_EventsBlocksHandlesErrors(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	tests := []struct {
+		name string
+
+		request interface{}
+
+		checkErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "empty events request",
+			request: request.EventsBlocks{},
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "invalid blockchain name",
+			request: request.EventsBlocks{
+				NetworkID: identifier.Network{
+					Blockchain: invalidBlockchain,
+					Network:    dps.FlowLocalnet.String(),
+				},
+			},
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork),
+		},
+		{
+			name: "invalid network name",
+			request: request.EventsBlocks{
+				NetworkID: identifier.Network{
+					Blockchain: dps.FlowBlockchain,
+					Network:    invalidNetwork,
+				},
+			},
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork),
+		},
+		{
+			name:    "negative offset",
+			request: []byte(`{"network_identifier":{"blockchain":"flow","network":"flow-localnet"},"offset":-1}`),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidEncoding),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			_, ctx, err := setupRecorder(eventsEndpoint, test.request)
+			require.NoError(t, err)
+
+			err = data.EventsBlocks(ctx)
+			test.checkErr(t, err)
+		})
+	}
+}
+
+func requestEvents(offset uint64, limit uint) request.EventsBlocks {
+	return request.EventsBlocks{
+		NetworkID: defaultNetwork(),
+		Offset:    offset,
+		Limit:     limit,
+	}
+}
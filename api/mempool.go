@@ -0,0 +1,51 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+// Mempool implements the /mempool endpoint of the Rosetta Data API. It lists
+// the transactions submitted through this server which the Flow Access API
+// still reports as pending.
+// See https://www.rosetta-api.org/docs/MempoolApi.html#mempool
+func (d *Data) Mempool(ctx echo.Context) error {
+
+	var req request.Mempool
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	err = d.validate.Request(req)
+	if err != nil {
+		return formatError(err)
+	}
+
+	rosTxIDs, err := d.pool.PendingTransactions()
+	if err != nil {
+		return apiError(mempoolRetrieval, err)
+	}
+
+	res := response.Mempool{
+		TransactionIDs: rosTxIDs,
+	}
+
+	return ctx.JSON(statusOK, res)
+}
@@ -0,0 +1,366 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/onflow/cadence"
+	cjson "github.com/onflow/cadence/encoding/json"
+	sdk "github.com/onflow/flow-go-sdk"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+	"github.com/optakt/flow-dps-rosetta/service/scripts"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+const (
+	mempoolSender   = "e2f72218abeec2b9"
+	mempoolReceiver = "06909bc5ba14c266"
+)
+
+func TestAPI_Mempool(t *testing.T) {
+
+	db := setupDB(t)
+
+	first := pendingTransfer(t, mempoolSender, mempoolReceiver, 1_00000000)
+	second := pendingTransfer(t, mempoolReceiver, mempoolSender, 2_00000000)
+	sealed := pendingTransfer(t, mempoolSender, mempoolReceiver, 3_00000000)
+
+	t.Run("nominal case", func(t *testing.T) {
+
+		access := mocks.BaselineAccessAPI(t)
+		access.GetTransactionResultFunc = func(_ context.Context, txID sdk.Identifier, _ ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			result := sdk.TransactionResult{
+				Status: sdk.TransactionStatusPending,
+			}
+			if txID == sealed.ID() {
+				result.Status = sdk.TransactionStatusSealed
+			}
+			return &result, nil
+		}
+
+		data, submit := setupMempoolAPI(t, db, access)
+		for _, tx := range []*sdk.Transaction{first, second, sealed} {
+			require.NoError(t, submit.Transaction(tx))
+		}
+
+		rec, ctx, err := setupRecorder(mempoolEndpoint, requestMempool())
+		require.NoError(t, err)
+
+		err = data.Mempool(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var res response.Mempool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+		want := []identifier.Transaction{
+			{Hash: first.ID().Hex()},
+			{Hash: second.ID().Hex()},
+		}
+		assert.ElementsMatch(t, want, res.TransactionIDs)
+	})
+
+	t.Run("empty mempool", func(t *testing.T) {
+
+		data, _ := setupMempoolAPI(t, db, mocks.BaselineAccessAPI(t))
+
+		rec, ctx, err := setupRecorder(mempoolEndpoint, requestMempool())
+		require.NoError(t, err)
+
+		err = data.Mempool(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		assert.JSONEq(t, `{"transaction_identifiers":[]}`, rec.Body.String())
+	})
+}
+
+func TestAPI_MempoolHandlesErrors(t *testing.T) {
+
+	db := setupDB(t)
+
+	t.Run("invalid requests", func(t *testing.T) {
+
+		data, _ := setupMempoolAPI(t, db, mocks.BaselineAccessAPI(t))
+
+		tests := []struct {
+			name string
+
+			request request.Mempool
+
+			checkErr assert.ErrorAssertionFunc
+		}{
+			{
+				name:    "empty mempool request",
+				request: request.Mempool{},
+
+				checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+			},
+			{
+				name: "invalid blockchain name",
+				request: request.Mempool{
+					NetworkID: identifier.Network{
+						Blockchain: invalidBlockchain,
+						Network:    dps.FlowLocalnet.String(),
+					},
+				},
+
+				checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork),
+			},
+			{
+				name: "invalid network name",
+				request: request.Mempool{
+					NetworkID: identifier.Network{
+						Blockchain: dps.FlowBlockchain,
+						Network:    invalidNetwork,
+					},
+				},
+
+				checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork),
+			},
+		}
+
+		for _, test := range tests {
+			test := test
+			t.Run(test.name, func(t *testing.T) {
+
+				_, ctx, err := setupRecorder(mempoolEndpoint, test.request)
+				require.NoError(t, err)
+
+				err = data.Mempool(ctx)
+				test.checkErr(t, err)
+			})
+		}
+	})
+
+	t.Run("access API failure", func(t *testing.T) {
+
+		access := mocks.BaselineAccessAPI(t)
+		access.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			return nil, mocks.GenericError
+		}
+
+		data, submit := setupMempoolAPI(t, db, access)
+		require.NoError(t, submit.Transaction(pendingTransfer(t, mempoolSender, mempoolReceiver, 1_00000000)))
+
+		rec, ctx, err := setupRecorder(mempoolEndpoint, requestMempool())
+		require.NoError(t, err)
+
+		// Transactions whose status can not be looked up are left out, rather
+		// than failing the whole request.
+		err = data.Mempool(ctx)
+		require.NoError(t, err)
+
+		var res response.Mempool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.NotNil(t, res.TransactionIDs)
+		assert.Empty(t, res.TransactionIDs)
+	})
+}
+
+func TestAPI_MempoolTransaction(t *testing.T) {
+
+	db := setupDB(t)
+
+	tx := pendingTransfer(t, mempoolSender, mempoolReceiver, 5_00000000)
+
+	data, submit := setupMempoolAPI(t, db, mocks.BaselineAccessAPI(t))
+	require.NoError(t, submit.Transaction(tx))
+
+	rec, ctx, err := setupRecorder(mempoolTxEndpoint, requestMempoolTransaction(tx.ID().Hex()))
+	require.NoError(t, err)
+
+	err = data.MempoolTransaction(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+	var res response.MempoolTransaction
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.NotNil(t, res.Transaction)
+
+	assert.Equal(t, tx.ID().Hex(), res.Transaction.ID.Hash)
+	require.Len(t, res.Transaction.Operations, 2)
+
+	send := res.Transaction.Operations[0]
+	assert.Equal(t, mempoolSender, send.AccountID.Address)
+	assert.Equal(t, dps.OperationTransfer, send.Type)
+	assert.Equal(t, "-500000000", send.Amount.Value)
+	assert.Equal(t, dps.FlowSymbol, send.Amount.Currency.Symbol)
+	assert.Empty(t, send.Status)
+
+	receive := res.Transaction.Operations[1]
+	assert.Equal(t, mempoolReceiver, receive.AccountID.Address)
+	assert.Equal(t, dps.OperationTransfer, receive.Type)
+	assert.Equal(t, "500000000", receive.Amount.Value)
+	assert.Equal(t, dps.FlowSymbol, receive.Amount.Currency.Symbol)
+	assert.Empty(t, receive.Status)
+}
+
+func TestAPI_MempoolTransactionHandlesErrors(t *testing.T) {
+
+	db := setupDB(t)
+
+	tx := pendingTransfer(t, mempoolSender, mempoolReceiver, 5_00000000)
+	sealed := pendingTransfer(t, mempoolSender, mempoolReceiver, 6_00000000)
+
+	access := mocks.BaselineAccessAPI(t)
+	access.GetTransactionResultFunc = func(_ context.Context, txID sdk.Identifier, _ ...grpc.CallOption) (*sdk.TransactionResult, error) {
+		result := sdk.TransactionResult{
+			Status: sdk.TransactionStatusPending,
+		}
+		if txID == sealed.ID() {
+			result.Status = sdk.TransactionStatusSealed
+		}
+		return &result, nil
+	}
+
+	data, submit := setupMempoolAPI(t, db, access)
+	require.NoError(t, submit.Transaction(tx))
+	require.NoError(t, submit.Transaction(sealed))
+
+	const (
+		trimmedTxHash = "88419614bf6cda15586bb686f33eea15835db13c0f9f997dcce275afb325102"  // tx hash a character short
+		invalidTxHash = "88419614bf6cda15586bb686f33eea15835db13c0f9f997dcce275afb325102z" // tx hash with a hex-invalid last character
+		unknownTxHash = "4262ac5a22fc593917a332fa80872ff88a57ccb211a3636a498b433149da4dee" // indexed tx that was never submitted
+	)
+
+	tests := []struct {
+		name string
+
+		request request.MempoolTransaction
+
+		checkErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "empty mempool transaction request",
+			request: request.MempoolTransaction{},
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "invalid network name",
+			request: request.MempoolTransaction{
+				NetworkID: identifier.Network{
+					Blockchain: dps.FlowBlockchain,
+					Network:    invalidNetwork,
+				},
+				TransactionID: identifier.Transaction{Hash: tx.ID().Hex()},
+			},
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork),
+		},
+		{
+			name:    "missing transaction hash",
+			request: requestMempoolTransaction(""),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name:    "invalid length of transaction hash",
+			request: requestMempoolTransaction(trimmedTxHash),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name:    "invalid transaction hash",
+			request: requestMempoolTransaction(invalidTxHash),
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidTransaction),
+		},
+		{
+			name:    "transaction never submitted",
+			request: requestMempoolTransaction(unknownTxHash),
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorUnknownTransaction),
+		},
+		{
+			name:    "transaction no longer pending",
+			request: requestMempoolTransaction(sealed.ID().Hex()),
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorUnknownTransaction),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+
+			_, ctx, err := setupRecorder(mempoolTxEndpoint, test.request)
+			require.NoError(t, err)
+
+			err = data.MempoolTransaction(ctx)
+			test.checkErr(t, err)
+		})
+	}
+}
+
+func requestMempool() request.Mempool {
+	return request.Mempool{
+		NetworkID: defaultNetwork(),
+	}
+}
+
+func requestMempoolTransaction(hash string) request.MempoolTransaction {
+	return request.MempoolTransaction{
+		NetworkID: defaultNetwork(),
+		TransactionID: identifier.Transaction{
+			Hash: hash,
+		},
+	}
+}
+
+// pendingTransfer returns an unsigned token transfer transaction between the
+// given localnet accounts, as it would be submitted to the Access API.
+func pendingTransfer(t *testing.T, from string, to string, amount uint64) *sdk.Transaction {
+	t.Helper()
+
+	generate := scripts.NewGenerator(dps.FlowParams[dps.FlowLocalnet])
+	script, err := generate.TransferTokens(dps.FlowSymbol)
+	require.NoError(t, err)
+
+	amountArg, err := cjson.Encode(cadence.UFix64(amount))
+	require.NoError(t, err)
+	receiverArg, err := cjson.Encode(cadence.NewAddress(sdk.HexToAddress(to)))
+	require.NoError(t, err)
+
+	sender := sdk.HexToAddress(from)
+	tx := sdk.NewTransaction().
+		SetScript(script).
+		SetPayer(sender).
+		SetProposalKey(sender, 0, amount).
+		AddAuthorizer(sender).
+		AddRawArgument(amountArg).
+		AddRawArgument(receiverArg)
+
+	return tx
+}
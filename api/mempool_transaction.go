@@ -0,0 +1,51 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+// MempoolTransaction implements the /mempool/transaction endpoint of the Rosetta
+// Data API. It returns the operations that a pending transaction is expected to
+// perform once it is executed.
+// See https://www.rosetta-api.org/docs/MempoolApi.html#mempooltransaction
+func (d *Data) MempoolTransaction(ctx echo.Context) error {
+
+	var req request.MempoolTransaction
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	err = d.validate.Request(req)
+	if err != nil {
+		return formatError(err)
+	}
+
+	transaction, err := d.pool.PendingTransaction(req.TransactionID)
+	if err != nil {
+		return apiError(txRetrieval, err)
+	}
+
+	res := response.MempoolTransaction{
+		Transaction: transaction,
+	}
+
+	return ctx.JSON(statusOK, res)
+}
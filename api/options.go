@@ -44,7 +44,7 @@ func (d *Data) Options(ctx echo.Context) error {
 		Errors:                  d.config.Errors(),
 		HistoricalBalanceLookup: true,
 		CallMethods:             []string{},
-		BalanceExemptions:       []struct{}{},
+		BalanceExemptions:       d.config.Exemptions(),
 		MempoolCoins:            false,
 	}
 
@@ -66,15 +66,30 @@ func TestAPI_Options(t *testing.T) {
 	assert.Regexp(t, versionRe, options.Version.MiddlewareVersion)
 
 	assert.True(t, options.Allow.HistoricalBalanceLookup)
+	assert.NotNil(t, options.Allow.BalanceExemptions)
+	assert.Empty(t, options.Allow.BalanceExemptions)
 
-	require.Len(t, options.Allow.OperationStatuses, 1)
+	require.Len(t, options.Allow.OperationStatuses, 2)
 
 	status := options.Allow.OperationStatuses[0]
 	assert.Equal(t, status.Status, dps.StatusCompleted)
 	assert.True(t, status.Successful)
 
-	require.Len(t, options.Allow.OperationTypes, 1)
-	assert.Equal(t, options.Allow.OperationTypes[0], dps.OperationTransfer)
+	status = options.Allow.OperationStatuses[1]
+	assert.Equal(t, status.Status, configuration.StatusFailed.Status)
+	assert.False(t, status.Successful)
+
+	wantTypes := []string{
+		dps.OperationTransfer,
+		configuration.OperationFee,
+		configuration.OperationStake,
+		configuration.OperationUnstake,
+		configuration.OperationReward,
+		configuration.OperationCreateAccount,
+		configuration.OperationMint,
+		configuration.OperationBurn,
+	}
+	assert.Equal(t, wantTypes, options.Allow.OperationTypes)
 
 	require.Len(t, options.Allow.Errors, wantErrorCount)
 
@@ -23,9 +23,13 @@ import (
 
 type Retriever interface {
 	Oldest() (identifier.Block, time.Time, error)
+	Genesis() (identifier.Block, error)
 	Current() (identifier.Block, time.Time, error)
+	Peers() ([]object.Peer, error)
 	Block(rosBlockID identifier.Block) (*object.Block, []identifier.Transaction, error)
+	BlockTransactions(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error)
 	Transaction(rosBlockID identifier.Block, rosTxID identifier.Transaction) (*object.Transaction, error)
 	Balances(rosBlockID identifier.Block, rosAccountID identifier.Account, rosCurrencies []identifier.Currency) (identifier.Block, []object.Amount, error)
+	BalanceHistory(rosAccountID identifier.Account, rosCurrency identifier.Currency, rosStartID identifier.Block, rosEndID identifier.Block) ([]object.BalancePoint, error)
 	Sequence(rosBlockID identifier.Block, rosAccountID identifier.Account, index int) (uint64, error)
 }
@@ -0,0 +1,143 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+)
+
+// Router routes requests to the Data and Construction APIs of the Flow network
+// given by their network identifier, which allows serving multiple networks
+// with a single server.
+type Router struct {
+	networks  []identifier.Network
+	data      map[string]*Data
+	construct map[string]*Construction
+}
+
+// NewRouter creates a new router without any networks.
+func NewRouter() *Router {
+	r := Router{
+		networks:  []identifier.Network{},
+		data:      make(map[string]*Data),
+		construct: make(map[string]*Construction),
+	}
+	return &r
+}
+
+// Add adds the Data and Construction APIs of a network to the router. Both APIs
+// need to be configured for the same network, and each network can only be
+// added once.
+func (r *Router) Add(data *Data, construct *Construction) error {
+
+	network := data.config.Network()
+	if construct.config.Network() != network {
+		return fmt.Errorf("mismatching networks for data and construction APIs (data: %s, construction: %s)", network.Network, construct.config.Network().Network)
+	}
+	_, ok := r.data[network.Network]
+	if ok {
+		return fmt.Errorf("duplicate network (network: %s)", network.Network)
+	}
+
+	r.networks = append(r.networks, network)
+	r.data[network.Network] = data
+	r.construct[network.Network] = construct
+
+	return nil
+}
+
+// Networks implements the /network/list endpoint of the Rosetta Data API for
+// all of the networks of the router.
+// See https://www.rosetta-api.org/docs/NetworkApi.html#networklist
+func (r *Router) Networks(ctx echo.Context) error {
+
+	var req request.Networks
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	res := response.Networks{
+		NetworkIDs: r.networks,
+	}
+
+	return ctx.JSON(statusOK, res)
+}
+
+// Data returns a handler that executes the given Data API handler on the Data
+// API of the network given in the request.
+func (r *Router) Data(handler func(*Data, echo.Context) error) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		network, err := r.network(ctx)
+		if err != nil {
+			return err
+		}
+		return handler(r.data[network], ctx)
+	}
+}
+
+// Construction returns a handler that executes the given Construction API
+// handler on the Construction API of the network given in the request.
+func (r *Router) Construction(handler func(*Construction, echo.Context) error) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		network, err := r.network(ctx)
+		if err != nil {
+			return err
+		}
+		return handler(r.construct[network], ctx)
+	}
+}
+
+// network peeks at the network identifier of the request and returns the name of
+// the network to route it to. The request body is restored, so that it can still be
+// bound by the handler. Requests that are not valid JSON or that are for an unknown
+// network are routed to the first network, so that its handler returns the same
+// errors as it would on a server with a single network.
+func (r *Router) network(ctx echo.Context) (string, error) {
+
+	if len(r.networks) == 0 {
+		return "", apiError(networkRouting, fmt.Errorf("no networks configured"))
+	}
+
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return "", unpackError(err)
+	}
+	ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		NetworkID identifier.Network `json:"network_identifier"`
+	}
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		return r.networks[0].Network, nil
+	}
+
+	_, ok := r.data[req.NetworkID.Network]
+	if !ok {
+		return r.networks[0].Network, nil
+	}
+
+	return req.NetworkID.Network, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rosetta "github.com/optakt/flow-dps-rosetta/api"
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/meta"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+	"github.com/optakt/flow-dps-rosetta/service/validator"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+const preprocessEndpoint = "/construction/preprocess"
+
+func TestAPI_Router(t *testing.T) {
+
+	db := setupDB(t)
+	router := setupRouter(t, db)
+
+	testnet := identifier.Network{
+		Blockchain: dps.FlowBlockchain,
+		Network:    dps.FlowTestnet.String(),
+	}
+
+	t.Run("lists all networks", func(t *testing.T) {
+		rec, ctx, err := setupRecorder(listEndpoint, request.Networks{})
+		require.NoError(t, err)
+
+		err = router.Networks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var networks response.Networks
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &networks))
+
+		assert.Equal(t, []identifier.Network{defaultNetwork(), testnet}, networks.NetworkIDs)
+	})
+
+	t.Run("routes to first network", func(t *testing.T) {
+		req := request.Status{
+			NetworkID: defaultNetwork(),
+		}
+
+		rec, ctx, err := setupRecorder(statusEndpoint, req)
+		require.NoError(t, err)
+
+		err = router.Data((*rosetta.Data).Status)(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var status response.Status
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+
+		require.NotNil(t, status.CurrentBlockID.Index)
+		assert.Equal(t, knownHeader(173).Height, *status.CurrentBlockID.Index)
+	})
+
+	t.Run("routes to second network", func(t *testing.T) {
+		req := request.Options{
+			NetworkID: testnet,
+		}
+
+		rec, ctx, err := setupRecorder(optionsEndpoint, req)
+		require.NoError(t, err)
+
+		err = router.Data((*rosetta.Data).Options)(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var options response.Options
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &options))
+
+		assert.Equal(t, testnetExemptions(), options.Allow.BalanceExemptions)
+	})
+
+	t.Run("routes construction requests", func(t *testing.T) {
+		req := request.Preprocess{
+			NetworkID: identifier.Network{
+				Blockchain: dps.FlowBlockchain,
+				Network:    invalidNetwork,
+			},
+		}
+
+		_, ctx, err := setupRecorder(preprocessEndpoint, req)
+		require.NoError(t, err)
+
+		err = router.Construction((*rosetta.Construction).Preprocess)(ctx)
+
+		checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork)(t, err)
+	})
+
+	t.Run("handles unknown network", func(t *testing.T) {
+		req := request.Options{
+			NetworkID: identifier.Network{
+				Blockchain: dps.FlowBlockchain,
+				Network:    invalidNetwork,
+			},
+		}
+
+		_, ctx, err := setupRecorder(optionsEndpoint, req)
+		require.NoError(t, err)
+
+		err = router.Data((*rosetta.Data).Options)(ctx)
+
+		checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork)(t, err)
+	})
+
+	t.Run("handles invalid JSON", func(t *testing.T) {
+		_, ctx, err := setupRecorder(optionsEndpoint, []byte(`{`))
+		require.NoError(t, err)
+
+		err = router.Data((*rosetta.Data).Options)(ctx)
+
+		checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidEncoding)(t, err)
+	})
+
+	t.Run("handles duplicate network", func(t *testing.T) {
+		data := setupAPI(t, db)
+		construct := rosetta.NewConstruction(configuration.New(dps.FlowLocalnet), nil, nil, nil)
+
+		err := router.Add(data, construct)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles mismatching networks", func(t *testing.T) {
+		data := setupAPI(t, db)
+		construct := rosetta.NewConstruction(configuration.New(dps.FlowMainnet), nil, nil, nil)
+
+		err := rosetta.NewRouter().Add(data, construct)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles empty router", func(t *testing.T) {
+		_, ctx, err := setupRecorder(optionsEndpoint, request.Options{NetworkID: defaultNetwork()})
+		require.NoError(t, err)
+
+		err = rosetta.NewRouter().Data((*rosetta.Data).Options)(ctx)
+
+		checkRosettaError(http.StatusInternalServerError, configuration.ErrorInternal)(t, err)
+	})
+}
+
+// setupRouter returns a router for the localnet network of the snapshot, as well
+// as for a testnet network with balance exemptions that only serves its options.
+func setupRouter(t *testing.T, db *badger.DB) *rosetta.Router {
+	t.Helper()
+
+	router := rosetta.NewRouter()
+	index := index.NewReader(db, storage.New(zbor.NewCodec()))
+
+	config := configuration.New(dps.FlowLocalnet)
+	validate := validator.New(dps.FlowParams[dps.FlowLocalnet], index, config)
+	construct := rosetta.NewConstruction(config, nil, nil, validate)
+	err := router.Add(setupAPI(t, db), construct)
+	require.NoError(t, err)
+
+	config = configuration.New(dps.FlowTestnet, configuration.WithExemptions(testnetExemptions()))
+	validate = validator.New(dps.FlowParams[dps.FlowTestnet], index, config)
+	data := rosetta.NewData(config, nil, validate, nil, nil, nil, nil)
+	construct = rosetta.NewConstruction(config, nil, nil, validate)
+	err = router.Add(data, construct)
+	require.NoError(t, err)
+
+	return router
+}
+
+func testnetExemptions() []meta.BalanceExemption {
+	return []meta.BalanceExemption{
+		{
+			Account:       identifier.Account{Address: "8624b52f9ddcd04a"},
+			Currency:      identifier.Currency{Symbol: dps.FlowSymbol, Decimals: dps.FlowDecimals},
+			ExemptionType: configuration.ExemptionDynamic,
+		},
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+	"github.com/optakt/flow-dps-rosetta/service/search"
+)
+
+// SearchTransactions implements the /search/transactions endpoint of the Rosetta
+// Data API. It returns the transactions matching all of the given filters, from
+// the most recent to the oldest.
+// See https://www.rosetta-api.org/docs/SearchApi.html#searchtransactions
+func (d *Data) SearchTransactions(ctx echo.Context) error {
+
+	var req request.SearchTransactions
+	err := ctx.Bind(&req)
+	if err != nil {
+		return unpackError(err)
+	}
+
+	err = d.validate.Request(req)
+	if err != nil {
+		return formatError(err)
+	}
+
+	query := search.Query{
+		AccountID:     req.AccountID,
+		TransactionID: req.TransactionID,
+		Type:          req.Type,
+		Success:       req.Success,
+		MaxBlock:      req.MaxBlock,
+		Offset:        req.Offset,
+		Limit:         req.Limit,
+	}
+	transactions, total, err := d.search.Transactions(query)
+	if err != nil {
+		return apiError(searchRetrieval, err)
+	}
+
+	res := response.SearchTransactions{
+		Transactions: transactions,
+		TotalCount:   total,
+	}
+	next := req.Offset + uint(len(transactions))
+	if next < total {
+		res.NextOffset = &next
+	}
+
+	return ctx.JSON(statusOK, res)
+}
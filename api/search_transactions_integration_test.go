@@ -0,0 +1,358 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build integration
+// +build integration
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
+	"github.com/optakt/flow-dps-rosetta/service/request"
+	"github.com/optakt/flow-dps-rosetta/service/response"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+func TestAPI_SearchTransactions(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	const (
+		sender   = "06909bc5ba14c266"
+		receiver = "e2f72218abeec2b9"
+
+		createTx8  = "d4fb2448b70b22392ec9c98eeecb066c7d3b912005b583b81cb5efb05053e9b5"
+		createTx25 = "6c53b2628b0a0b4ab26e1f33ce780a9aed33becb9911bb2bb5492e3905eeaf23"
+		createTx41 = "1348bbea50a47434811cda3028a9daa42c308316a7b9d1a01e9eb68e239fb0a1"
+		transferTx = "2d394a7841c91c5470e6e3cabb1e7ed57609ef41117bba84ced01d37659f2861"
+		midBlockTx = "9cb22148c60e23001dc1d22a8d16fa74bb6363674e2b1a8f6f1c02b34a9a5e11"
+		lastTx     = "d7b8696b9a73550c228168d1fc5b771d35356d10eb7bba98edd1408d36a2f92b"
+
+		totalTxs = 46
+	)
+
+	tests := []struct {
+		name string
+
+		request request.SearchTransactions
+
+		wantHashes     []string
+		wantTotal      uint
+		wantNextOffset *uint
+	}{
+		{
+			name: "account with maximum block",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.AccountID = &identifier.Account{Address: sender}
+				req.MaxBlock = getUint64P(57)
+			}),
+			wantHashes: []string{midBlockTx, transferTx, createTx25},
+			wantTotal:  3,
+		},
+		{
+			name: "account with maximum block and limit",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.AccountID = &identifier.Account{Address: sender}
+				req.MaxBlock = getUint64P(57)
+				req.Limit = 2
+			}),
+			wantHashes:     []string{midBlockTx, transferTx},
+			wantTotal:      3,
+			wantNextOffset: getUintP(2),
+		},
+		{
+			name: "account with maximum block and offset",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.AccountID = &identifier.Account{Address: sender}
+				req.MaxBlock = getUint64P(57)
+				req.Offset = 2
+			}),
+			wantHashes: []string{createTx25},
+			wantTotal:  3,
+		},
+		{
+			name: "operation type with maximum block",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.Type = configuration.OperationCreateAccount
+				req.MaxBlock = getUint64P(41)
+			}),
+			wantHashes: []string{createTx41, createTx25, createTx8},
+			wantTotal:  3,
+		},
+		{
+			name: "transaction",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.TransactionID = &identifier.Transaction{Hash: transferTx}
+			}),
+			wantHashes: []string{transferTx},
+			wantTotal:  1,
+		},
+		{
+			name: "transaction with involved account",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.TransactionID = &identifier.Transaction{Hash: transferTx}
+				req.AccountID = &identifier.Account{Address: receiver}
+				req.Type = configuration.OperationTransfer
+			}),
+			wantHashes: []string{transferTx},
+			wantTotal:  1,
+		},
+		{
+			name: "transaction with uninvolved account",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.TransactionID = &identifier.Transaction{Hash: lastTx}
+				req.AccountID = &identifier.Account{Address: receiver}
+			}),
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name: "successful transactions",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.Success = getBoolP(true)
+				req.Limit = 1
+			}),
+			wantHashes:     []string{lastTx},
+			wantTotal:      2,
+			wantNextOffset: getUintP(1),
+		},
+		{
+			name: "successful transactions on last page",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.Success = getBoolP(true)
+				req.Offset = totalTxs - 1
+			}),
+			wantHashes: []string{createTx8},
+			wantTotal:  totalTxs,
+		},
+		{
+			name: "failed transactions",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.Success = getBoolP(false)
+			}),
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name: "explicit and operator",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.Operator = "and"
+				req.TransactionID = &identifier.Transaction{Hash: lastTx}
+			}),
+			wantHashes: []string{lastTx},
+			wantTotal:  1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			rec, ctx, err := setupRecorder(searchEndpoint, test.request)
+			require.NoError(t, err)
+
+			err = data.SearchTransactions(ctx)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+			var res response.SearchTransactions
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+			assert.Equal(t, test.wantTotal, res.TotalCount)
+			assert.Equal(t, test.wantNextOffset, res.NextOffset)
+			require.Len(t, res.Transactions, len(test.wantHashes))
+			for i, transaction := range res.Transactions {
+				assert.Equal(t, test.wantHashes[i], transaction.Transaction.ID.Hash)
+				assert.NotEmpty(t, transaction.Transaction.Operations)
+			}
+		})
+	}
+
+	t.Run("block identifiers match blocks", func(t *testing.T) {
+
+		req := requestSearch(func(req *request.SearchTransactions) {
+			req.AccountID = &identifier.Account{Address: sender}
+			req.MaxBlock = getUint64P(57)
+		})
+		rec, ctx, err := setupRecorder(searchEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.SearchTransactions(ctx)
+		require.NoError(t, err)
+
+		var res response.SearchTransactions
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Transactions, 3)
+
+		for i, height := range []uint64{57, 47} {
+			header := knownHeader(height)
+			rosBlockID := res.Transactions[i].BlockID
+			require.NotNil(t, rosBlockID.Index)
+			assert.Equal(t, header.Height, *rosBlockID.Index)
+			assert.Equal(t, header.ID().String(), rosBlockID.Hash)
+		}
+	})
+
+	t.Run("transactions match block transactions", func(t *testing.T) {
+
+		req := requestSearch(func(req *request.SearchTransactions) {
+			req.TransactionID = &identifier.Transaction{Hash: transferTx}
+		})
+		rec, ctx, err := setupRecorder(searchEndpoint, req)
+		require.NoError(t, err)
+
+		err = data.SearchTransactions(ctx)
+		require.NoError(t, err)
+
+		var res response.SearchTransactions
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Transactions, 1)
+
+		validateTransfer(t, transferTx, receiver, sender, 5_00000000)([]*object.Transaction{res.Transactions[0].Transaction})
+	})
+}
+
+func TestAPI_SearchTransactionsHandlesErrors(t *testing.T) {
+
+	db := setupDB(t)
+	data := setupAPI(t, db)
+
+	const (
+		trimmedTxHash     = "2d394a7841c91c5470e6e3cabb1e7ed57609ef41117bba84ced01d37659f286"  // tx hash a character short
+		invalidTxHash     = "2d394a7841c91c5470e6e3cabb1e7ed57609ef41117bba84ced01d37659f286z" // tx hash with a hex-invalid last character
+		trimmedAddress    = "06909bc5ba14c26"                                                  // account address a character short
+		invalidAddressHex = "06909bc5ba14c26z"                                                 // account address with a hex-invalid last character
+		sender            = "06909bc5ba14c266"                                                 // account address of a known sender
+	)
+
+	tests := []struct {
+		name string
+
+		request interface{}
+
+		checkErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "empty search request",
+			request: request.SearchTransactions{},
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "invalid network name",
+			request: request.SearchTransactions{
+				NetworkID: identifier.Network{
+					Blockchain: dps.FlowBlockchain,
+					Network:    invalidNetwork,
+				},
+			},
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidNetwork),
+		},
+		{
+			name: "unsupported operator",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.Operator = "or"
+			}),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "invalid length of transaction hash",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.TransactionID = &identifier.Transaction{Hash: trimmedTxHash}
+			}),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "invalid transaction hash",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.TransactionID = &identifier.Transaction{Hash: invalidTxHash}
+			}),
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidTransaction),
+		},
+		{
+			name: "invalid length of account address",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.AccountID = &identifier.Account{Address: trimmedAddress}
+			}),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "account with sub-account",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.AccountID = &identifier.Account{
+					Address:    sender,
+					SubAccount: &identifier.SubAccount{Address: sender},
+				}
+			}),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidFormat),
+		},
+		{
+			name: "invalid account address",
+			request: requestSearch(func(req *request.SearchTransactions) {
+				req.AccountID = &identifier.Account{Address: invalidAddressHex}
+			}),
+
+			checkErr: checkRosettaError(http.StatusUnprocessableEntity, configuration.ErrorInvalidAccount),
+		},
+		{
+			name:    "negative maximum block",
+			request: []byte(`{"network_identifier":{"blockchain":"flow","network":"flow-localnet"},"max_block":-1}`),
+
+			checkErr: checkRosettaError(http.StatusBadRequest, configuration.ErrorInvalidEncoding),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			_, ctx, err := setupRecorder(searchEndpoint, test.request)
+			require.NoError(t, err)
+
+			err = data.SearchTransactions(ctx)
+			test.checkErr(t, err)
+		})
+	}
+}
+
+func requestSearch(opts ...func(*request.SearchTransactions)) request.SearchTransactions {
+	req := request.SearchTransactions{
+		NetworkID: defaultNetwork(),
+	}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	return req
+}
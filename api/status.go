@@ -17,6 +17,7 @@ package api
 import (
 	"github.com/labstack/echo/v4"
 
+	"github.com/optakt/flow-dps-rosetta/service/object"
 	"github.com/optakt/flow-dps-rosetta/service/request"
 	"github.com/optakt/flow-dps-rosetta/service/response"
 )
@@ -41,17 +42,39 @@ func (d *Data) Status(ctx echo.Context) error {
 		return apiError(oldestRetrieval, err)
 	}
 
+	genesis, err := d.retrieve.Genesis()
+	if err != nil {
+		return apiError(genesisRetrieval, err)
+	}
+
 	current, timestamp, err := d.retrieve.Current()
 	if err != nil {
 		return apiError(currentRetrieval, err)
 	}
 
+	// The sync status and the peers are optional, so if the Access API or the
+	// staking table script fails, we omit them rather than failing the request,
+	// as load balancers rely on this endpoint for their health checks. The
+	// failures are still logged, so that outages can be noticed.
+	sync, err := d.track.Sync()
+	if err != nil {
+		ctx.Logger().Warnf("could not get sync status: %v", err)
+		sync = nil
+	}
+
+	peers, err := d.retrieve.Peers()
+	if err != nil {
+		ctx.Logger().Warnf("could not get peers: %v", err)
+		peers = []object.Peer{}
+	}
+
 	res := response.Status{
 		CurrentBlockID:        current,
 		CurrentBlockTimestamp: timestamp.UnixNano() / 1_000_000,
 		OldestBlockID:         oldest,
-		GenesisBlockID:        oldest,
-		Peers:                 []struct{}{},
+		GenesisBlockID:        genesis,
+		SyncStatus:            sync,
+		Peers:                 peers,
 	}
 
 	return ctx.JSON(statusOK, res)
@@ -18,6 +18,8 @@
 package api_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"testing"
@@ -25,12 +27,18 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/ziflex/lecho/v2"
+	"google.golang.org/grpc"
+
+	sdk "github.com/onflow/flow-go-sdk"
 
 	rosetta "github.com/optakt/flow-dps-rosetta/api"
 	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/service/request"
 	"github.com/optakt/flow-dps-rosetta/service/response"
+	"github.com/optakt/flow-dps-rosetta/service/tracker"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
 	"github.com/optakt/flow-dps/models/convert"
 	"github.com/optakt/flow-dps/models/dps"
 )
@@ -76,6 +84,51 @@ func TestAPI_Status(t *testing.T) {
 	genesisBlockHeight := status.GenesisBlockID.Index
 	require.NotNil(t, genesisBlockHeight)
 	assert.Equal(t, *genesisBlockHeight, uint64(0))
+
+	require.NotNil(t, status.SyncStatus)
+	assert.Equal(t, lastBlock.Height, status.SyncStatus.CurrentIndex)
+	assert.Equal(t, lastBlock.Height+accessLag, status.SyncStatus.TargetIndex)
+	assert.Equal(t, tracker.StageSynced, status.SyncStatus.Stage)
+	assert.True(t, status.SyncStatus.Synced)
+
+	// The nodes of the localnet snapshot are not registered in the staking
+	// table, so the list of peers is empty, but it should still be present.
+	require.NotNil(t, status.Peers)
+	assert.Empty(t, status.Peers)
+}
+
+func TestAPI_StatusWithoutAccessAPI(t *testing.T) {
+
+	db := setupDB(t)
+
+	access := mocks.BaselineAccessAPI(t)
+	access.GetLatestBlockHeaderFunc = func(context.Context, bool, ...grpc.CallOption) (*sdk.BlockHeader, error) {
+		return nil, mocks.GenericError
+	}
+	data := setupStatusAPI(t, db, access)
+
+	rec, ctx, err := setupRecorder(statusEndpoint, request.Status{NetworkID: defaultNetwork()})
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	ctx.Echo().Logger = lecho.New(&logs)
+
+	err = data.Status(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+	var status response.Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+
+	lastBlock := knownHeader(173)
+	assert.Equal(t, lastBlock.ID().String(), status.CurrentBlockID.Hash)
+	assert.Nil(t, status.SyncStatus)
+	assert.NotNil(t, status.Peers)
+
+	// The omitted sync status is still logged as a warning.
+	assert.Contains(t, logs.String(), `"level":"warn"`)
+	assert.Contains(t, logs.String(), "could not get sync status")
 }
 
 func TestAPI_StatusHandlesErrors(t *testing.T) {
@@ -46,6 +46,8 @@ func (d *Data) Transaction(ctx echo.Context) error {
 		return apiError(txRetrieval, err)
 	}
 
+	immutable(ctx, req.BlockID)
+
 	res := response.Transaction{
 		Transaction: transaction,
 	}
@@ -21,8 +21,11 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/dgraph-io/badger/v2"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog"
 	"github.com/spf13/pflag"
@@ -30,6 +33,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/onflow/flow-go-sdk/client"
+	"github.com/onflow/flow-go/model/flow"
 
 	api "github.com/optakt/flow-dps/api/dps"
 	"github.com/optakt/flow-dps/codec/zbor"
@@ -37,13 +41,21 @@ import (
 	"github.com/optakt/flow-dps/service/invoker"
 
 	rosetta "github.com/optakt/flow-dps-rosetta/api"
+	"github.com/optakt/flow-dps-rosetta/service/cache"
 	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/converter"
+	"github.com/optakt/flow-dps-rosetta/service/events"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/meta"
 	"github.com/optakt/flow-dps-rosetta/service/retriever"
 	"github.com/optakt/flow-dps-rosetta/service/scripts"
+	"github.com/optakt/flow-dps-rosetta/service/search"
+	"github.com/optakt/flow-dps-rosetta/service/spork"
 	"github.com/optakt/flow-dps-rosetta/service/submitter"
+	"github.com/optakt/flow-dps-rosetta/service/tracker"
 	"github.com/optakt/flow-dps-rosetta/service/transactor"
 	"github.com/optakt/flow-dps-rosetta/service/validator"
+	"github.com/optakt/flow-dps-rosetta/service/vault"
 )
 
 const (
@@ -63,21 +75,39 @@ func run() int {
 
 	// Command line parameter initialization.
 	var (
-		flagDPS          string
+		flagDPS          []string
 		flagAccess       string
 		flagCache        uint64
+		flagResponses    uint64
+		flagExemptions   string
+		flagEvents       string
+		flagNetworks     string
+		flagSearch       string
+		flagPrevious     string
+		flagGenesis      string
 		flagLevel        string
 		flagPort         uint16
 		flagTransactions uint
+		flagHistory      uint64
+		flagTolerance    uint64
 		flagSmart        bool
 	)
 
-	pflag.StringVarP(&flagDPS, "dps-api", "a", "127.0.0.1:5005", "host address for GRPC API endpoint")
+	pflag.StringSliceVarP(&flagDPS, "dps-api", "a", []string{"127.0.0.1:5005"}, "host addresses for GRPC API endpoints, one per spork, ordered from oldest to most recent")
 	pflag.StringVarP(&flagAccess, "access-api", "c", "access.canary.nodes.onflow.org:9000", "host address for Flow network's Access API endpoint")
-	pflag.Uint64VarP(&flagCache, "cache", "e", 1_000_000_000, "maximum cache size for register reads in bytes")
+	pflag.Uint64VarP(&flagCache, "cache", "e", 1_000_000_000, "maximum cache size for register reads in bytes, per network")
+	pflag.Uint64Var(&flagResponses, "response-cache", 100_000_000, "maximum cache size for blocks, transactions and balances of sealed blocks in bytes, per network")
+	pflag.StringVarP(&flagExemptions, "exemptions", "x", "", "path to JSON file with balance exemptions for the Rosetta API")
+	pflag.StringVar(&flagEvents, "event-log", "events", "path to directory for the block event log databases, with one subdirectory per network")
+	pflag.StringVarP(&flagNetworks, "networks", "n", "", "path to JSON file with the DPS and Access API endpoints of each network to serve, instead of the ones given by flags")
+	pflag.StringVar(&flagSearch, "search-index", "search", "path to directory for the transaction search index databases, with one subdirectory per network")
+	pflag.StringVar(&flagPrevious, "previous-block", "", "height and hash of the last block of the previous spork as <height>:<hash>, or \"root\" to derive it from the root block")
+	pflag.StringVar(&flagGenesis, "genesis-block", "", "height and hash of the genesis block of the network as <height>:<hash>, if not the first indexed block")
 	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
 	pflag.Uint16VarP(&flagPort, "port", "p", 8080, "port to host Rosetta API on")
 	pflag.UintVarP(&flagTransactions, "transaction-limit", "t", 200, "maximum amount of transactions to include in a block response")
+	pflag.Uint64Var(&flagHistory, "history-limit", 1000, "maximum amount of blocks in a balance history range")
+	pflag.Uint64Var(&flagTolerance, "sync-tolerance", 10, "maximum amount of blocks the index can lag behind the latest sealed block to be considered synced")
 	pflag.BoolVar(&flagSmart, "smart-status-codes", false, "enable smart non-500 HTTP status codes for Rosetta API errors")
 
 	pflag.Parse()
@@ -96,75 +126,234 @@ func run() int {
 	// Initialize codec.
 	codec := zbor.NewCodec()
 
-	// Initialize the DPS API client and wrap it for easy usage.
-	conn, err := grpc.Dial(flagDPS, grpc.WithInsecure())
-	if err != nil {
-		log.Error().Str("api", flagDPS).Err(err).Msg("could not dial API host")
-		return failure
-	}
-	defer conn.Close()
-	dpsAPI := api.NewAPIClient(conn)
-	index := api.IndexFromAPI(dpsAPI, codec)
-
-	// Deduce chain ID from DPS API to configure parameters for script exec.
-	first, err := index.First()
-	if err != nil {
-		log.Error().Err(err).Msg("could not get first height from DPS API")
-		return failure
-	}
-	root, err := index.Header(first)
-	if err != nil {
-		log.Error().Uint64("first", first).Err(err).Msg("could not get root header from DPS API")
-		return failure
-	}
-	params, ok := dps.FlowParams[root.ChainID]
-	if !ok {
-		log.Error().Str("chain", root.ChainID.String()).Msg("invalid chain ID for params")
-		return failure
-	}
-
-	// Initialize the SDK client.
-	if flagAccess == "" {
-		log.Error().Msg("Flow Access API endpoint is missing")
-		return failure
-	}
-	accessAPI, err := client.New(flagAccess, grpc.WithInsecure())
-	if err != nil {
-		log.Error().Str("address", flagAccess).Err(err).Msg("could not dial Flow Access API address")
-		return failure
-	}
-	defer accessAPI.Close()
-
 	// If smart status codes are enabled for the Rosetta API, we change the HTTP
 	// status code constants here.
 	if flagSmart {
 		rosetta.EnableSmartCodes()
 	}
 
-	// Rosetta API initialization.
-	config := configuration.New(params.ChainID)
-	validate := validator.New(params, index, config)
-	generate := scripts.NewGenerator(params)
-	invoke, err := invoker.New(index, invoker.WithCacheSize(flagCache))
-	if err != nil {
-		log.Error().Err(err).Msg("could not initialize invoker")
-		return failure
+	// If a network backends file is given, we serve each of the networks it
+	// lists. Otherwise, we serve a single network with the endpoints and the
+	// balance exemptions given on the command line.
+	backends := []configuration.Backend{{
+		DPSAPI:        flagDPS,
+		AccessAPI:     flagAccess,
+		Exemptions:    flagExemptions,
+		PreviousBlock: flagPrevious,
+		GenesisBlock:  flagGenesis,
+	}}
+	if flagNetworks != "" {
+		file, err := os.Open(flagNetworks)
+		if err != nil {
+			log.Error().Str("networks", flagNetworks).Err(err).Msg("could not open network backends file")
+			return failure
+		}
+		backends, err = configuration.ReadBackends(file)
+		_ = file.Close()
+		if err != nil {
+			log.Error().Str("networks", flagNetworks).Err(err).Msg("could not read network backends file")
+			return failure
+		}
 	}
 
-	convert, err := converter.New(generate)
-	if err != nil {
-		log.Error().Err(err).Msg("could not generate transaction event types")
-		return failure
-	}
+	// Each request is routed to the Rosetta API components of the network
+	// given in its network identifier.
+	router := rosetta.NewRouter()
+	updaters := make([]func(context.Context), 0, 2*len(backends))
+	for _, backend := range backends {
 
-	retrieve := retriever.New(params, index, validate, generate, invoke, convert,
-		retriever.WithTransactionLimit(flagTransactions),
-	)
-	dataCtrl := rosetta.NewData(config, retrieve, validate)
+		// Initialize a DPS API client for each spork and wrap them for easy usage.
+		if len(backend.DPSAPI) == 0 {
+			log.Error().Msg("DPS API endpoint is missing")
+			return failure
+		}
+		sporks := make([]dps.Reader, 0, len(backend.DPSAPI))
+		for _, address := range backend.DPSAPI {
+			conn, err := grpc.Dial(address, grpc.WithInsecure())
+			if err != nil {
+				log.Error().Str("api", address).Err(err).Msg("could not dial API host")
+				return failure
+			}
+			defer conn.Close()
+			dpsAPI := api.NewAPIClient(conn)
+			sporks = append(sporks, api.IndexFromAPI(dpsAPI, codec))
+		}
+
+		// Route each request to the DPS API of the spork it belongs to.
+		index, err := spork.NewIndex(sporks...)
+		if err != nil {
+			log.Error().Strs("api", backend.DPSAPI).Err(err).Msg("could not initialize spork index")
+			return failure
+		}
+
+		// Deduce chain ID from DPS API to configure parameters for script exec.
+		first, err := index.First()
+		if err != nil {
+			log.Error().Err(err).Msg("could not get first height from DPS API")
+			return failure
+		}
+		root, err := index.Header(first)
+		if err != nil {
+			log.Error().Uint64("first", first).Err(err).Msg("could not get root header from DPS API")
+			return failure
+		}
+		params, ok := dps.FlowParams[root.ChainID]
+		if !ok {
+			log.Error().Str("chain", root.ChainID.String()).Msg("invalid chain ID for params")
+			return failure
+		}
+
+		// Initialize the SDK client.
+		if backend.AccessAPI == "" {
+			log.Error().Msg("Flow Access API endpoint is missing")
+			return failure
+		}
+		accessAPI, err := client.New(backend.AccessAPI, grpc.WithInsecure())
+		if err != nil {
+			log.Error().Str("address", backend.AccessAPI).Err(err).Msg("could not dial Flow Access API address")
+			return failure
+		}
+		defer accessAPI.Close()
+
+		// If a balance exemptions file is given, we load the exemptions so they
+		// can be served to clients by the Rosetta API.
+		exemptions := []meta.BalanceExemption{}
+		if backend.Exemptions != "" {
+			file, err := os.Open(backend.Exemptions)
+			if err != nil {
+				log.Error().Str("exemptions", backend.Exemptions).Err(err).Msg("could not open balance exemptions file")
+				return failure
+			}
+			exemptions, err = configuration.ReadExemptions(file)
+			_ = file.Close()
+			if err != nil {
+				log.Error().Str("exemptions", backend.Exemptions).Err(err).Msg("could not read balance exemptions file")
+				return failure
+			}
+		}
+
+		// Rosetta API initialization.
+		config := configuration.New(params.ChainID, configuration.WithExemptions(exemptions))
+		validate := validator.New(params, index, config)
+		generate := scripts.NewGenerator(params)
+		invoke, err := invoker.New(index, invoker.WithCacheSize(flagCache))
+		if err != nil {
+			log.Error().Err(err).Msg("could not initialize invoker")
+			return failure
+		}
 
-	submit := submitter.New(accessAPI)
-	transact := transactor.New(validate, generate, invoke, submit)
-	constructCtrl := rosetta.NewConstruction(config, transact, retrieve, validate)
+		convert, err := converter.New(params, generate)
+		if err != nil {
+			log.Error().Err(err).Msg("could not generate transaction event types")
+			return failure
+		}
+
+		options := []func(*retriever.Config){
+			retriever.WithTransactionLimit(flagTransactions),
+			retriever.WithHistoryLimit(flagHistory),
+		}
+
+		// If the index does not start at the genesis block, its first block can be
+		// linked to the last block of the previous spork, which is either given
+		// explicitly or derived from the parent of the root block.
+		switch backend.PreviousBlock {
+		case "":
+		case configuration.PreviousRoot:
+			if first == 0 || root.ParentID == flow.ZeroID {
+				log.Error().Uint64("first", first).Msg("root block has no parent to link to")
+				return failure
+			}
+			height := first - 1
+			previous := identifier.Block{
+				Index: &height,
+				Hash:  root.ParentID.String(),
+			}
+			options = append(options, retriever.WithPrevious(previous))
+		default:
+			previous, err := configuration.ParseBlock(backend.PreviousBlock)
+			if err != nil {
+				log.Error().Str("previous", backend.PreviousBlock).Err(err).Msg("could not parse previous block")
+				return failure
+			}
+			options = append(options, retriever.WithPrevious(previous))
+		}
+		if backend.GenesisBlock != "" {
+			genesis, err := configuration.ParseBlock(backend.GenesisBlock)
+			if err != nil {
+				log.Error().Str("genesis", backend.GenesisBlock).Err(err).Msg("could not parse genesis block")
+				return failure
+			}
+			options = append(options, retriever.WithGenesis(genesis))
+		}
+
+		read := vault.New(params, index)
+		responses, err := cache.New(cache.WithCacheSize(flagResponses))
+		if err != nil {
+			log.Error().Err(err).Msg("could not initialize response cache")
+			return failure
+		}
+		retrieve := retriever.New(params, index, validate, generate, invoke, read, convert, responses, options...)
+		track := tracker.New(index, accessAPI, tracker.WithTolerance(flagTolerance))
+		submit := submitter.New(accessAPI)
+		transact := transactor.New(validate, generate, invoke, submit)
+
+		// The block event log of each network is kept in its own database, so
+		// that the sequence numbers of its events stay stable across restarts.
+		dir := filepath.Join(flagEvents, params.ChainID.String())
+		db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		if err != nil {
+			log.Error().Str("event_log", dir).Err(err).Msg("could not open block event log database")
+			return failure
+		}
+		defer db.Close()
+		follow, err := events.New(db, index)
+		if err != nil {
+			log.Error().Str("event_log", dir).Err(err).Msg("could not initialize block event log")
+			return failure
+		}
+
+		// The transaction search index of each network is also kept in its own
+		// database, so that it only needs to index new blocks after a restart.
+		dir = filepath.Join(flagSearch, params.ChainID.String())
+		db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		if err != nil {
+			log.Error().Str("search_index", dir).Err(err).Msg("could not open transaction search index database")
+			return failure
+		}
+		defer db.Close()
+		find, err := search.New(db, index, validate, retrieve)
+		if err != nil {
+			log.Error().Str("search_index", dir).Err(err).Msg("could not initialize transaction search index")
+			return failure
+		}
+
+		chain := params.ChainID.String()
+		updaters = append(updaters,
+			func(ctx context.Context) {
+				err := follow.Update(ctx)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					log.Warn().Str("chain", chain).Err(err).Msg("could not update block event log")
+				}
+			},
+			func(ctx context.Context) {
+				err := find.Update(ctx)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					log.Warn().Str("chain", chain).Err(err).Msg("could not update transaction search index")
+				}
+			},
+		)
+
+		dataCtrl := rosetta.NewData(config, retrieve, validate, track, transact, follow, find)
+		constructCtrl := rosetta.NewConstruction(config, transact, retrieve, validate)
+
+		err = router.Add(dataCtrl, constructCtrl)
+		if err != nil {
+			log.Error().Str("chain", params.ChainID.String()).Err(err).Msg("could not add network to router")
+			return failure
+		}
+
+		log.Info().Str("chain", params.ChainID.String()).Int("sporks", len(sporks)).Msg("network initialized")
+	}
 
 	server := echo.New()
 	server.HideBanner = true
@@ -173,21 +362,29 @@ func run() int {
 	server.Use(lecho.Middleware(lecho.Config{Logger: elog}))
 
 	// This group contains all of the Rosetta Data API endpoints.
-	server.POST("/network/list", dataCtrl.Networks)
-	server.POST("/network/options", dataCtrl.Options)
-	server.POST("/network/status", dataCtrl.Status)
-	server.POST("/account/balance", dataCtrl.Balance)
-	server.POST("/block", dataCtrl.Block)
-	server.POST("/block/transaction", dataCtrl.Transaction)
+	server.POST("/network/list", router.Networks)
+	server.POST("/network/options", router.Data((*rosetta.Data).Options))
+	server.POST("/network/status", router.Data((*rosetta.Data).Status))
+	server.POST("/account/balance", router.Data((*rosetta.Data).Balance))
+	server.POST("/block", router.Data((*rosetta.Data).Block))
+	server.POST("/block/transaction", router.Data((*rosetta.Data).Transaction))
+	server.POST("/mempool", router.Data((*rosetta.Data).Mempool))
+	server.POST("/mempool/transaction", router.Data((*rosetta.Data).MempoolTransaction))
+	server.POST("/events/blocks", router.Data((*rosetta.Data).EventsBlocks))
+	server.POST("/search/transactions", router.Data((*rosetta.Data).SearchTransactions))
+
+	// This group contains non-standard Data API endpoints.
+	server.POST("/block/transactions", router.Data((*rosetta.Data).BlockTransactions))
+	server.POST("/account/balance/history", router.Data((*rosetta.Data).BalanceHistory))
 
 	// This group contains all of the Rosetta Construction API endpoints.
-	server.POST("/construction/preprocess", constructCtrl.Preprocess)
-	server.POST("/construction/metadata", constructCtrl.Metadata)
-	server.POST("/construction/payloads", constructCtrl.Payloads)
-	server.POST("/construction/parse", constructCtrl.Parse)
-	server.POST("/construction/combine", constructCtrl.Combine)
-	server.POST("/construction/hash", constructCtrl.Hash)
-	server.POST("/construction/submit", constructCtrl.Submit)
+	server.POST("/construction/preprocess", router.Construction((*rosetta.Construction).Preprocess))
+	server.POST("/construction/metadata", router.Construction((*rosetta.Construction).Metadata))
+	server.POST("/construction/payloads", router.Construction((*rosetta.Construction).Payloads))
+	server.POST("/construction/parse", router.Construction((*rosetta.Construction).Parse))
+	server.POST("/construction/combine", router.Construction((*rosetta.Construction).Combine))
+	server.POST("/construction/hash", router.Construction((*rosetta.Construction).Hash))
+	server.POST("/construction/submit", router.Construction((*rosetta.Construction).Submit))
 
 	// This section launches the main executing components in their own
 	// goroutine, so they can run concurrently. Afterwards, we wait for an
@@ -206,6 +403,32 @@ func run() int {
 		log.Info().Msg("Flow Rosetta Server stopped")
 	}()
 
+	// The block event log and the transaction search index of each network
+	// are updated in the background, as new blocks are indexed. On shutdown,
+	// the updates are stopped before the databases are closed.
+	updates, stop := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, update := range updaters {
+		wg.Add(1)
+		go func(update func(context.Context)) {
+			defer wg.Done()
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				update(updates)
+				select {
+				case <-updates.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(update)
+	}
+	defer func() {
+		stop()
+		wg.Wait()
+	}()
+
 	select {
 	case <-sig:
 		log.Info().Msg("Flow Rosetta Server stopping")
@@ -0,0 +1,111 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dgraph-io/ristretto"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache is a size-bounded cache for values that never change once they can be
+// loaded, such as the converted blocks, transactions and balances at sealed
+// heights. Concurrent requests for the same missing key are coalesced, so that
+// the value is only loaded once and shared between all of the requesters.
+type Cache struct {
+	cache  *ristretto.Cache
+	flight singleflight.Group
+}
+
+// New returns a new Cache with the given configuration. A cache size of zero
+// disables caching, while still coalescing concurrent loads.
+func New(options ...func(*Config)) (*Cache, error) {
+
+	// Initialize the cache configuration with conservative default values.
+	cfg := Config{
+		CacheSize: uint64(100_000_000), // ~100 MB default size
+	}
+
+	// Apply the option parameters provided by consumer.
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	c := Cache{}
+
+	if cfg.CacheSize == 0 {
+		return &c, nil
+	}
+
+	// Initialize the Ristretto cache with the size limit. The cost of each
+	// value is the size of its JSON encoding, which is a good approximation of
+	// its size in memory and exactly what the API will respond with. Ristretto
+	// recommends keeping ten times as many counters as items in the cache when
+	// full; assuming an average item size of 1 kilobyte, this is what we get.
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: int64(cfg.CacheSize)/1000*10 + 1,
+		MaxCost:     int64(cfg.CacheSize),
+		BufferItems: 64,
+		Cost:        cost,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize cache: %w", err)
+	}
+
+	c.cache = cache
+
+	return &c, nil
+}
+
+// Get returns the value cached for the given key. If there is none, it uses the
+// given function to load the value and caches it, unless loading it failed. If
+// the same key is already being loaded, it waits for that load to finish and
+// returns its result instead.
+func (c *Cache) Get(key string, load func() (interface{}, error)) (interface{}, error) {
+
+	value, ok := c.cache.Get(key)
+	if ok {
+		return value, nil
+	}
+
+	value, err, _ := c.flight.Do(key, func() (interface{}, error) {
+
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		// Setting the value in the cache is asynchronous; we wait for it to be
+		// applied, so that requests after this one see the cached value instead
+		// of loading it again.
+		c.cache.Set(key, value, 0)
+		c.cache.Wait()
+
+		return value, nil
+	})
+
+	return value, err
+}
+
+// cost returns the size of the JSON encoding of the given value.
+func cost(value interface{}) int64 {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 1
+	}
+	return int64(len(data))
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := New(WithCacheSize(1_000_000))
+
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		assert.NotNil(t, c.cache)
+		assert.Equal(t, int64(1_000_000), c.cache.MaxCost())
+	})
+
+	t.Run("caching disabled", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := New(WithCacheSize(0))
+
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		assert.Nil(t, c.cache)
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cache_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/cache"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestCache_Get(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := cache.New()
+		require.NoError(t, err)
+
+		var calls int
+		load := func() (interface{}, error) {
+			calls++
+			return "value", nil
+		}
+
+		value, err := c.Get("key", load)
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		value, err = c.Get("key", load)
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("different keys are loaded separately", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := cache.New()
+		require.NoError(t, err)
+
+		first, err := c.Get("first", func() (interface{}, error) { return "first", nil })
+		require.NoError(t, err)
+		second, err := c.Get("second", func() (interface{}, error) { return "second", nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, "first", first)
+		assert.Equal(t, "second", second)
+	})
+
+	t.Run("failed loads are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := cache.New()
+		require.NoError(t, err)
+
+		_, err = c.Get("key", func() (interface{}, error) { return nil, mocks.GenericError })
+		assert.ErrorIs(t, err, mocks.GenericError)
+
+		value, err := c.Get("key", func() (interface{}, error) { return "value", nil })
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("caching disabled", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := cache.New(cache.WithCacheSize(0))
+		require.NoError(t, err)
+
+		var calls int
+		load := func() (interface{}, error) {
+			calls++
+			return "value", nil
+		}
+
+		_, err = c.Get("key", load)
+		require.NoError(t, err)
+		_, err = c.Get("key", load)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("concurrent loads are coalesced", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := cache.New()
+		require.NoError(t, err)
+
+		// The first load blocks until it is released, while the other requests
+		// for the same key are started; they should wait for it instead of
+		// loading the value themselves.
+		var calls int32
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		load := func() (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				close(entered)
+			}
+			<-release
+			return "value", nil
+		}
+
+		const requests = 8
+		values := make([]interface{}, requests)
+		var wg sync.WaitGroup
+		wg.Add(requests)
+		for i := 0; i < requests; i++ {
+			go func(i int) {
+				defer wg.Done()
+				value, err := c.Get("key", load)
+				assert.NoError(t, err)
+				values[i] = value
+			}(i)
+		}
+
+		<-entered
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for _, value := range values {
+			assert.Equal(t, "value", value)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Backend contains the endpoints used to serve one Flow network: the DPS API
+// endpoints of its sporks, ordered from oldest to most recent, and its Access
+// API endpoint, as well as an optional path to its balance exemptions file.
+// The previous and genesis blocks optionally identify the last block before the
+// first indexed block and the genesis block of the network, when the index does
+// not start at the genesis block.
+type Backend struct {
+	DPSAPI        []string `json:"dps_api"`
+	AccessAPI     string   `json:"access_api"`
+	Exemptions    string   `json:"exemptions,omitempty"`
+	PreviousBlock string   `json:"previous_block,omitempty"`
+	GenesisBlock  string   `json:"genesis_block,omitempty"`
+}
+
+// ReadBackends reads a list of network backends encoded as JSON.
+func ReadBackends(r io.Reader) ([]Backend, error) {
+
+	var backends []Backend
+	err := json.NewDecoder(r).Decode(&backends)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode network backends: %w", err)
+	}
+
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("missing network backends")
+	}
+
+	for i, backend := range backends {
+		if len(backend.DPSAPI) == 0 {
+			return nil, fmt.Errorf("missing DPS API endpoints for network backend (index: %d)", i)
+		}
+		for _, address := range backend.DPSAPI {
+			if address == "" {
+				return nil, fmt.Errorf("empty DPS API endpoint for network backend (index: %d)", i)
+			}
+		}
+		if backend.AccessAPI == "" {
+			return nil, fmt.Errorf("missing Access API endpoint for network backend (index: %d)", i)
+		}
+	}
+
+	return backends, nil
+}
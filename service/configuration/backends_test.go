@@ -0,0 +1,93 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+)
+
+func TestReadBackends(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		input := `[
+			{"dps_api": ["127.0.0.1:5005", "127.0.0.1:5006"], "access_api": "access.mainnet.nodes.onflow.org:9000", "exemptions": "exemptions.json"},
+			{"dps_api": ["127.0.0.1:5007"], "access_api": "access.devnet.nodes.onflow.org:9000"}
+		]`
+
+		backends, err := configuration.ReadBackends(strings.NewReader(input))
+
+		require.NoError(t, err)
+		want := []configuration.Backend{
+			{
+				DPSAPI:     []string{"127.0.0.1:5005", "127.0.0.1:5006"},
+				AccessAPI:  "access.mainnet.nodes.onflow.org:9000",
+				Exemptions: "exemptions.json",
+			},
+			{
+				DPSAPI:    []string{"127.0.0.1:5007"},
+				AccessAPI: "access.devnet.nodes.onflow.org:9000",
+			},
+		}
+		assert.Equal(t, want, backends)
+	})
+
+	t.Run("handles invalid JSON", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ReadBackends(strings.NewReader(`{`))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles empty list", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ReadBackends(strings.NewReader(`[]`))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing DPS API endpoints", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ReadBackends(strings.NewReader(`[{"access_api": "127.0.0.1:9000"}]`))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles empty DPS API endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ReadBackends(strings.NewReader(`[{"dps_api": [""], "access_api": "127.0.0.1:9000"}]`))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing Access API endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ReadBackends(strings.NewReader(`[{"dps_api": ["127.0.0.1:5005"]}]`))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// PreviousRoot can be configured instead of the identifier of the previous spork's
+// last block, to derive it from the parent of the root block of the index.
+const PreviousRoot = "root"
+
+// ParseBlock parses a block identifier given as its height and its hash,
+// separated by a colon, such as `13404173:4cd8...`.
+func ParseBlock(block string) (identifier.Block, error) {
+
+	parts := strings.Split(block, ":")
+	if len(parts) != 2 {
+		return identifier.Block{}, fmt.Errorf("invalid block identifier format (have: %s, want: <height>:<hash>)", block)
+	}
+
+	height, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return identifier.Block{}, fmt.Errorf("could not parse block height: %w", err)
+	}
+
+	blockID, err := flow.HexStringToIdentifier(parts[1])
+	if err != nil {
+		return identifier.Block{}, fmt.Errorf("could not parse block hash: %w", err)
+	}
+
+	rosBlockID := identifier.Block{
+		Index: &height,
+		Hash:  blockID.String(),
+	}
+
+	return rosBlockID, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+)
+
+func TestParseBlock(t *testing.T) {
+
+	hash := "4cd8a2a2e8dc6a2b7b1e1e1e1e6d0bd1d3c3b3a2a1a09f8f7f6f5f4f3f2f1f0e"
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		block, err := configuration.ParseBlock("13404173:" + hash)
+
+		require.NoError(t, err)
+		require.NotNil(t, block.Index)
+		assert.Equal(t, uint64(13404173), *block.Index)
+		assert.Equal(t, hash, block.Hash)
+	})
+
+	t.Run("handles missing hash", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ParseBlock("13404173")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles too many parts", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ParseBlock("13404173:" + hash + ":1")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid height", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ParseBlock("-1:" + hash)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid hash", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ParseBlock("13404173:" + hash[:62] + "zz")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles short hash", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ParseBlock("13404173:" + hash[:32])
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/meta"
+)
+
+// Config is the configuration for the Rosetta configuration component.
+type Config struct {
+	Exemptions []meta.BalanceExemption
+}
+
+// WithExemptions sets the balance exemptions in a Config.
+func WithExemptions(exemptions []meta.BalanceExemption) func(*Config) {
+	return func(c *Config) {
+		c.Exemptions = exemptions
+	}
+}
@@ -34,10 +34,19 @@ type Configuration struct {
 	statuses   []meta.StatusDefinition
 	operations []string
 	errors     []meta.ErrorDefinition
+	exemptions []meta.BalanceExemption
 }
 
 // New returns the configuration for a given Flow chain.
-func New(chain flow.ChainID) *Configuration {
+func New(chain flow.ChainID, options ...func(*Config)) *Configuration {
+
+	cfg := Config{
+		Exemptions: []meta.BalanceExemption{},
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
 
 	network := identifier.Network{
 		Blockchain: dps.FlowBlockchain,
@@ -52,10 +61,18 @@ func New(chain flow.ChainID) *Configuration {
 
 	statuses := []meta.StatusDefinition{
 		StatusCompleted,
+		StatusFailed,
 	}
 
 	operations := []string{
 		OperationTransfer,
+		OperationFee,
+		OperationStake,
+		OperationUnstake,
+		OperationReward,
+		OperationCreateAccount,
+		OperationMint,
+		OperationBurn,
 	}
 
 	errors := []meta.ErrorDefinition{
@@ -91,6 +108,7 @@ func New(chain flow.ChainID) *Configuration {
 		statuses:   statuses,
 		operations: operations,
 		errors:     errors,
+		exemptions: cfg.Exemptions,
 	}
 
 	return &c
@@ -121,6 +139,11 @@ func (c *Configuration) Errors() []meta.ErrorDefinition {
 	return c.errors
 }
 
+// Exemptions returns the configuration's balance exemptions.
+func (c *Configuration) Exemptions() []meta.BalanceExemption {
+	return c.exemptions
+}
+
 // Check verifies whether a network identifier matches with the configured one.
 func (c *Configuration) Check(network identifier.Network) error {
 	if network.Blockchain != c.network.Blockchain {
@@ -0,0 +1,60 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/optakt/flow-dps-rosetta/service/meta"
+)
+
+// Balance exemption types.
+const (
+	ExemptionGreaterOrEqual = "greater_or_equal"
+	ExemptionLessOrEqual    = "less_or_equal"
+	ExemptionDynamic        = "dynamic"
+)
+
+// ReadExemptions reads a list of balance exemptions encoded as JSON. The format
+// is compatible with the exemptions file of the Rosetta CLI; exemptions that do
+// not specify an exemption type are considered to be dynamic.
+func ReadExemptions(r io.Reader) ([]meta.BalanceExemption, error) {
+
+	var exemptions []meta.BalanceExemption
+	err := json.NewDecoder(r).Decode(&exemptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode balance exemptions: %w", err)
+	}
+
+	for i, exemption := range exemptions {
+		if exemption.Account.Address == "" {
+			return nil, fmt.Errorf("missing account address for balance exemption (index: %d)", i)
+		}
+		if exemption.Currency.Symbol == "" {
+			return nil, fmt.Errorf("missing currency symbol for balance exemption (index: %d)", i)
+		}
+		switch exemption.ExemptionType {
+		case "":
+			exemptions[i].ExemptionType = ExemptionDynamic
+		case ExemptionGreaterOrEqual, ExemptionLessOrEqual, ExemptionDynamic:
+		default:
+			return nil, fmt.Errorf("invalid exemption type for balance exemption (index: %d, type: %s)", i, exemption.ExemptionType)
+		}
+	}
+
+	return exemptions, nil
+}
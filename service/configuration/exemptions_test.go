@@ -0,0 +1,94 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package configuration_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/meta"
+)
+
+func TestReadExemptions(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		input := `[
+			{"account_identifier": {"address": "8624b52f9ddcd04a"}, "currency": {"symbol": "FLOW", "decimals": 8}},
+			{"account_identifier": {"address": "c6c77b9f5c7a378f"}, "currency": {"symbol": "FLOW", "decimals": 8}, "exemption_type": "greater_or_equal"}
+		]`
+
+		exemptions, err := configuration.ReadExemptions(strings.NewReader(input))
+
+		require.NoError(t, err)
+		want := []meta.BalanceExemption{
+			{
+				Account:       identifier.Account{Address: "8624b52f9ddcd04a"},
+				Currency:      identifier.Currency{Symbol: "FLOW", Decimals: 8},
+				ExemptionType: configuration.ExemptionDynamic,
+			},
+			{
+				Account:       identifier.Account{Address: "c6c77b9f5c7a378f"},
+				Currency:      identifier.Currency{Symbol: "FLOW", Decimals: 8},
+				ExemptionType: configuration.ExemptionGreaterOrEqual,
+			},
+		}
+		assert.Equal(t, want, exemptions)
+	})
+
+	t.Run("handles invalid JSON", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := configuration.ReadExemptions(strings.NewReader(`{`))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing account address", func(t *testing.T) {
+		t.Parallel()
+
+		input := `[{"account_identifier": {}, "currency": {"symbol": "FLOW"}}]`
+
+		_, err := configuration.ReadExemptions(strings.NewReader(input))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing currency symbol", func(t *testing.T) {
+		t.Parallel()
+
+		input := `[{"account_identifier": {"address": "8624b52f9ddcd04a"}, "currency": {}}]`
+
+		_, err := configuration.ReadExemptions(strings.NewReader(input))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid exemption type", func(t *testing.T) {
+		t.Parallel()
+
+		input := `[{"account_identifier": {"address": "8624b52f9ddcd04a"}, "currency": {"symbol": "FLOW"}, "exemption_type": "invalid"}]`
+
+		_, err := configuration.ReadExemptions(strings.NewReader(input))
+
+		assert.Error(t, err)
+	})
+}
@@ -17,4 +17,12 @@ package configuration
 // Supported operations.
 const (
 	OperationTransfer = "TRANSFER"
+	OperationFee      = "FEE"
+	OperationMint     = "MINT"
+	OperationBurn     = "BURN"
+	OperationStake    = "STAKE"
+	OperationUnstake  = "UNSTAKE"
+	OperationReward   = "REWARD"
+
+	OperationCreateAccount = "CREATE_ACCOUNT"
 )
@@ -21,4 +21,5 @@ import (
 // Status definitions.
 var (
 	StatusCompleted = meta.StatusDefinition{Status: "COMPLETED", Successful: true}
+	StatusFailed    = meta.StatusDefinition{Status: "FAILED", Successful: false}
 )
@@ -22,6 +22,7 @@ import (
 	"github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/service/object"
 	"github.com/optakt/flow-dps-rosetta/service/retriever"
@@ -30,24 +31,82 @@ import (
 
 // Converter converts Flow Events into Rosetta Operations.
 type Converter struct {
-	deposit    flow.EventType
-	withdrawal flow.EventType
+	deposits    map[flow.EventType]string
+	withdrawals map[flow.EventType]string
+	mints       map[flow.EventType]string
+	burns       map[flow.EventType]string
+	fees        flow.EventType
+	created     flow.EventType
+	staking     map[flow.EventType]string
+	table       flow.Address
 }
 
-// New instantiates and returns a new converter using the given Generator.
-func New(gen Generator) (*Converter, error) {
-	deposit, err := gen.TokensDeposited(dps.FlowSymbol)
+// New instantiates and returns a new converter using the given Generator, which
+// supports the deposit, withdrawal, mint and burn events of all tokens in the given parameters,
+// as well as the transaction fee, account creation and staking events.
+func New(params dps.Params, gen Generator) (*Converter, error) {
+
+	fees, err := gen.FeesDeducted()
 	if err != nil {
-		return nil, fmt.Errorf("could not generate deposit event type: %w", err)
+		return nil, fmt.Errorf("could not generate fees event type: %w", err)
 	}
-	withdrawal, err := gen.TokensWithdrawn(dps.FlowSymbol)
+	created, err := gen.AccountCreated()
 	if err != nil {
-		return nil, fmt.Errorf("could not generate withdrawal event type: %w", err)
+		return nil, fmt.Errorf("could not generate account creation event type: %w", err)
 	}
 
 	c := Converter{
-		deposit:    flow.EventType(deposit),
-		withdrawal: flow.EventType(withdrawal),
+		deposits:    make(map[flow.EventType]string, len(params.Tokens)),
+		withdrawals: make(map[flow.EventType]string, len(params.Tokens)),
+		mints:       make(map[flow.EventType]string, len(params.Tokens)),
+		burns:       make(map[flow.EventType]string, len(params.Tokens)),
+		fees:        flow.EventType(fees),
+		created:     flow.EventType(created),
+		staking:     make(map[flow.EventType]string),
+		table:       params.StakingTable,
+	}
+
+	// Map each of the staking events to the type of operation it represents.
+	staking := []struct {
+		generate  func() (string, error)
+		operation string
+	}{
+		{generate: gen.TokensCommitted, operation: configuration.OperationStake},
+		{generate: gen.DelegatorTokensCommitted, operation: configuration.OperationStake},
+		{generate: gen.TokensUnstaked, operation: configuration.OperationUnstake},
+		{generate: gen.DelegatorTokensUnstaked, operation: configuration.OperationUnstake},
+		{generate: gen.RewardsPaid, operation: configuration.OperationReward},
+		{generate: gen.DelegatorRewardsPaid, operation: configuration.OperationReward},
+	}
+	for _, event := range staking {
+		typ, err := event.generate()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate staking event type (operation: %s): %w", event.operation, err)
+		}
+		c.staking[flow.EventType(typ)] = event.operation
+	}
+
+	for _, symbol := range params.Symbols() {
+		deposit, err := gen.TokensDeposited(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate deposit event type (symbol: %s): %w", symbol, err)
+		}
+		withdrawal, err := gen.TokensWithdrawn(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate withdrawal event type (symbol: %s): %w", symbol, err)
+		}
+		minted, err := gen.TokensMinted(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate mint event type (symbol: %s): %w", symbol, err)
+		}
+		burned, err := gen.TokensBurned(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate burn event type (symbol: %s): %w", symbol, err)
+		}
+		c.deposits[flow.EventType(deposit)] = symbol
+		c.withdrawals[flow.EventType(withdrawal)] = symbol
+		c.mints[flow.EventType(minted)] = symbol
+		c.burns[flow.EventType(burned)] = symbol
 	}
 
 	return &c, nil
@@ -56,6 +115,19 @@ func New(gen Generator) (*Converter, error) {
 // EventToOperation converts a flow.Event into a Rosetta Operation.
 func (c *Converter) EventToOperation(event flow.Event) (operation *object.Operation, err error) {
 
+	// Look up the kind of operation the event corresponds to before decoding it,
+	// so that we don't waste time on unsupported events.
+	depositSymbol, isDeposit := c.deposits[event.Type]
+	withdrawalSymbol, isWithdrawal := c.withdrawals[event.Type]
+	mintSymbol, isMint := c.mints[event.Type]
+	burnSymbol, isBurn := c.burns[event.Type]
+	isFee := event.Type == c.fees
+	isCreation := event.Type == c.created
+	stakingType, isStaking := c.staking[event.Type]
+	if !isDeposit && !isWithdrawal && !isMint && !isBurn && !isFee && !isCreation && !isStaking {
+		return nil, retriever.ErrNotSupported
+	}
+
 	// Decode the event payload into a Cadence value and cast it to a Cadence event.
 	value, err := json.Decode(event.Payload)
 	if err != nil {
@@ -66,6 +138,27 @@ func (c *Converter) EventToOperation(event flow.Event) (operation *object.Operat
 		return nil, fmt.Errorf("could not cast event: %w", err)
 	}
 
+	switch {
+	case isDeposit:
+		return c.transfer(event, e, depositSymbol, false)
+	case isWithdrawal:
+		return c.transfer(event, e, withdrawalSymbol, true)
+	case isMint:
+		return c.supply(event, e, mintSymbol, configuration.OperationMint)
+	case isBurn:
+		return c.supply(event, e, burnSymbol, configuration.OperationBurn)
+	case isFee:
+		return c.fee(event, e)
+	case isCreation:
+		return c.creation(event, e)
+	default:
+		return c.stake(event, e, stakingType)
+	}
+}
+
+// transfer converts a token deposit or withdrawal event into a transfer operation.
+func (c *Converter) transfer(event flow.Event, e cadence.Event, symbol string, withdrawal bool) (*object.Operation, error) {
+
 	// Ensure that there are the correct amount of fields.
 	if len(e.Fields) != 2 {
 		return nil, fmt.Errorf("invalid number of fields (want: %d, have: %d)", 2, len(e.Fields))
@@ -98,35 +191,208 @@ func (c *Converter) EventToOperation(event flow.Event) (operation *object.Operat
 	// Convert the address bytes into a native Flow address.
 	address := flow.Address(bAddress)
 
+	// In the case of a withdrawal, invert the amount value.
+	if withdrawal {
+		amount = -amount
+	}
+
 	netIndex := uint(event.EventIndex)
 	op := object.Operation{
 		ID: identifier.Operation{
 			NetworkIndex: &netIndex,
 		},
+		Type:   dps.OperationTransfer,
 		Status: dps.StatusCompleted,
 		AccountID: identifier.Account{
 			Address: address.String(),
 		},
+		Amount: &object.Amount{
+			Value: strconv.FormatInt(amount, 10),
+			Currency: identifier.Currency{
+				Symbol:   symbol,
+				Decimals: dps.FlowDecimals,
+			},
+		},
 	}
 
-	switch event.Type {
-	case c.deposit:
-		op.Type = dps.OperationTransfer
+	return &op, nil
+}
 
-	// In the case of a withdrawal, invert the amount value.
-	case c.withdrawal:
-		op.Type = dps.OperationTransfer
+// supply converts a mint or burn event into an operation of the given type. The
+// events do not contain the address of an account, as minted tokens are created in
+// a new vault and burned tokens are destroyed with their vault. The returned
+// operation is thus not associated with any account, and it is up to the retriever
+// to pair it with the deposit of the minted tokens, or the withdrawal of the burned
+// tokens. Minted amounts are credited, while burned amounts are debited.
+func (c *Converter) supply(event flow.Event, e cadence.Event, symbol string, typ string) (*object.Operation, error) {
+
+	// Ensure that there are the correct amount of fields; the only one is the
+	// amount of tokens minted or burned.
+	if len(e.Fields) != 1 {
+		return nil, fmt.Errorf("invalid number of fields (want: %d, have: %d)", 1, len(e.Fields))
+	}
+
+	vAmount := e.Fields[0].ToGoValue()
+	uAmount, ok := vAmount.(uint64)
+	if !ok {
+		return nil, fmt.Errorf("could not cast amount (%T)", vAmount)
+	}
+
+	amount := int64(uAmount)
+	if typ == configuration.OperationBurn {
 		amount = -amount
-	default:
-		return nil, retriever.ErrNotSupported
 	}
 
-	op.Amount = object.Amount{
-		Value: strconv.FormatInt(amount, 10),
-		Currency: identifier.Currency{
-			Symbol:   dps.FlowSymbol,
-			Decimals: dps.FlowDecimals,
+	netIndex := uint(event.EventIndex)
+	op := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &netIndex,
+		},
+		Type:   typ,
+		Status: dps.StatusCompleted,
+		Amount: &object.Amount{
+			Value: strconv.FormatInt(amount, 10),
+			Currency: identifier.Currency{
+				Symbol:   symbol,
+				Decimals: dps.FlowDecimals,
+			},
+		},
+	}
+
+	return &op, nil
+}
+
+// fee converts a fees deducted event into a fee operation. The event does not
+// contain the address of the payer, so the returned operation is not associated
+// with any account. It is up to the retriever to pair it with the withdrawal of
+// the fee amount from the payer's vault.
+func (c *Converter) fee(event flow.Event, e cadence.Event) (*object.Operation, error) {
+
+	// Ensure that there are the correct amount of fields; the first one is the
+	// amount, while the others are the inclusion and execution efforts.
+	if len(e.Fields) != 3 {
+		return nil, fmt.Errorf("invalid number of fields (want: %d, have: %d)", 3, len(e.Fields))
+	}
+
+	vAmount := e.Fields[0].ToGoValue()
+	uAmount, ok := vAmount.(uint64)
+	if !ok {
+		return nil, fmt.Errorf("could not cast amount (%T)", vAmount)
+	}
+
+	// Fees are always debited, so the amount is inverted.
+	amount := -int64(uAmount)
+
+	netIndex := uint(event.EventIndex)
+	op := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &netIndex,
+		},
+		Type:   configuration.OperationFee,
+		Status: dps.StatusCompleted,
+		Amount: &object.Amount{
+			Value: strconv.FormatInt(amount, 10),
+			Currency: identifier.Currency{
+				Symbol:   dps.FlowSymbol,
+				Decimals: dps.FlowDecimals,
+			},
+		},
+	}
+
+	return &op, nil
+}
+
+// creation converts an account creation event into an account creation operation
+// for the created account. The event does not contain the payer of the transaction
+// that created the account, so it is up to the retriever to add it to the metadata.
+// As the funding of the new account is represented by separate transfer operations,
+// the operation does not have an amount.
+func (c *Converter) creation(event flow.Event, e cadence.Event) (*object.Operation, error) {
+
+	// Ensure that there are the correct amount of fields; the only one is the
+	// address of the created account.
+	if len(e.Fields) != 1 {
+		return nil, fmt.Errorf("invalid number of fields (want: %d, have: %d)", 1, len(e.Fields))
+	}
+
+	vAddress := e.Fields[0].ToGoValue()
+	bAddress, ok := vAddress.([flow.AddressLength]byte)
+	if !ok {
+		return nil, fmt.Errorf("could not cast address (%T)", vAddress)
+	}
+	address := flow.Address(bAddress)
+
+	netIndex := uint(event.EventIndex)
+	op := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &netIndex,
+		},
+		Type:   configuration.OperationCreateAccount,
+		Status: dps.StatusCompleted,
+		AccountID: identifier.Account{
+			Address: address.String(),
+		},
+	}
+
+	return &op, nil
+}
+
+// stake converts a staking event into a staking operation of the given type. The
+// events only track the tokens moving between the buckets of the staking table,
+// and any tokens entering or leaving it are represented by the transfer operations
+// of the vaults involved. The operation is thus associated with the staking table
+// account but does not have an amount, so that it does not change any balance; the
+// node and delegator IDs, as well as the amount of tokens, are part of its metadata.
+func (c *Converter) stake(event flow.Event, e cadence.Event, typ string) (*object.Operation, error) {
+
+	// Staking events for nodes and delegators have different fields, so we
+	// look them up by name rather than by position.
+	values := fields(e)
+
+	vNodeID, ok := values["nodeID"]
+	if !ok {
+		return nil, fmt.Errorf("missing node ID field")
+	}
+	nodeID, ok := vNodeID.ToGoValue().(string)
+	if !ok {
+		return nil, fmt.Errorf("could not cast node ID (%T)", vNodeID.ToGoValue())
+	}
+
+	vAmount, ok := values["amount"]
+	if !ok {
+		return nil, fmt.Errorf("missing amount field")
+	}
+	uAmount, ok := vAmount.ToGoValue().(uint64)
+	if !ok {
+		return nil, fmt.Errorf("could not cast amount (%T)", vAmount.ToGoValue())
+	}
+
+	metadata := object.OperationMetadata{
+		NodeID: nodeID,
+		Tokens: strconv.FormatUint(uAmount, 10),
+	}
+
+	// The delegator ID is only present for events that relate to a delegator.
+	vDelegatorID, ok := values["delegatorID"]
+	if ok {
+		delegatorID, ok := vDelegatorID.ToGoValue().(uint32)
+		if !ok {
+			return nil, fmt.Errorf("could not cast delegator ID (%T)", vDelegatorID.ToGoValue())
+		}
+		metadata.DelegatorID = &delegatorID
+	}
+
+	netIndex := uint(event.EventIndex)
+	op := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &netIndex,
+		},
+		Type:   typ,
+		Status: dps.StatusCompleted,
+		AccountID: identifier.Account{
+			Address: c.table.String(),
 		},
+		Metadata: &metadata,
 	}
 
 	return &op, nil
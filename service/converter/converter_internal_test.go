@@ -15,6 +15,7 @@
 package converter
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,7 @@ import (
 	"github.com/onflow/cadence/runtime/tests/utils"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/service/object"
 	"github.com/optakt/flow-dps-rosetta/service/retriever"
@@ -33,22 +35,51 @@ import (
 )
 
 func TestNew(t *testing.T) {
+	params := mocks.GenericParams
+	params.Tokens = map[string]dps.Token{
+		dps.FlowSymbol: {Symbol: dps.FlowSymbol},
+		"TEST":         {Symbol: "TEST"},
+	}
+
 	t.Run("nominal case", func(t *testing.T) {
 		generator := mocks.BaselineGenerator(t)
 		generator.TokensDepositedFunc = func(symbol string) (string, error) {
-			assert.Equal(t, dps.FlowSymbol, symbol)
-			return string(mocks.GenericEventType(0)), nil
+			assert.Contains(t, params.Tokens, symbol)
+			return symbol + string(mocks.GenericEventType(0)), nil
 		}
 		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
-			assert.Equal(t, dps.FlowSymbol, symbol)
-			return string(mocks.GenericEventType(1)), nil
+			assert.Contains(t, params.Tokens, symbol)
+			return symbol + string(mocks.GenericEventType(1)), nil
+		}
+
+		generator.TokensMintedFunc = func(symbol string) (string, error) {
+			assert.Contains(t, params.Tokens, symbol)
+			return symbol + string(mocks.GenericEventType(10)), nil
+		}
+		generator.TokensBurnedFunc = func(symbol string) (string, error) {
+			assert.Contains(t, params.Tokens, symbol)
+			return symbol + string(mocks.GenericEventType(11)), nil
+		}
+		generator.FeesDeductedFunc = func() (string, error) {
+			return string(mocks.GenericEventType(2)), nil
 		}
 
-		cvt, err := New(generator)
+		cvt, err := New(params, generator)
 
 		require.NoError(t, err)
-		assert.Equal(t, cvt.deposit, mocks.GenericEventType(0))
-		assert.Equal(t, cvt.withdrawal, mocks.GenericEventType(1))
+		assert.Equal(t, mocks.GenericEventType(2), cvt.fees)
+		assert.Equal(t, mocks.GenericEventType(9), cvt.created)
+		assert.Len(t, cvt.staking, 6)
+		assert.Len(t, cvt.deposits, 2)
+		assert.Len(t, cvt.withdrawals, 2)
+		assert.Len(t, cvt.mints, 2)
+		assert.Len(t, cvt.burns, 2)
+		for symbol := range params.Tokens {
+			assert.Equal(t, symbol, cvt.deposits[flow.EventType(symbol)+mocks.GenericEventType(0)])
+			assert.Equal(t, symbol, cvt.withdrawals[flow.EventType(symbol)+mocks.GenericEventType(1)])
+			assert.Equal(t, symbol, cvt.mints[flow.EventType(symbol)+mocks.GenericEventType(10)])
+			assert.Equal(t, symbol, cvt.burns[flow.EventType(symbol)+mocks.GenericEventType(11)])
+		}
 	})
 
 	t.Run("handles generator failure for deposit event type", func(t *testing.T) {
@@ -57,7 +88,7 @@ func TestNew(t *testing.T) {
 			return "", mocks.GenericError
 		}
 
-		cvt, err := New(generator)
+		cvt, err := New(params, generator)
 
 		assert.Error(t, err)
 		assert.Nil(t, cvt)
@@ -69,7 +100,67 @@ func TestNew(t *testing.T) {
 			return "", mocks.GenericError
 		}
 
-		cvt, err := New(generator)
+		cvt, err := New(params, generator)
+
+		assert.Error(t, err)
+		assert.Nil(t, cvt)
+	})
+
+	t.Run("handles generator failure for account creation event type", func(t *testing.T) {
+		generator := mocks.BaselineGenerator(t)
+		generator.AccountCreatedFunc = func() (string, error) {
+			return "", mocks.GenericError
+		}
+
+		cvt, err := New(params, generator)
+
+		assert.Error(t, err)
+		assert.Nil(t, cvt)
+	})
+
+	t.Run("handles generator failure for staking event type", func(t *testing.T) {
+		generator := mocks.BaselineGenerator(t)
+		generator.RewardsPaidFunc = func() (string, error) {
+			return "", mocks.GenericError
+		}
+
+		cvt, err := New(params, generator)
+
+		assert.Error(t, err)
+		assert.Nil(t, cvt)
+	})
+
+	t.Run("handles generator failure for mint event type", func(t *testing.T) {
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensMintedFunc = func(symbol string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		cvt, err := New(params, generator)
+
+		assert.Error(t, err)
+		assert.Nil(t, cvt)
+	})
+
+	t.Run("handles generator failure for burn event type", func(t *testing.T) {
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensBurnedFunc = func(symbol string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		cvt, err := New(params, generator)
+
+		assert.Error(t, err)
+		assert.Nil(t, cvt)
+	})
+
+	t.Run("handles generator failure for fees event type", func(t *testing.T) {
+		generator := mocks.BaselineGenerator(t)
+		generator.FeesDeductedFunc = func() (string, error) {
+			return "", mocks.GenericError
+		}
+
+		cvt, err := New(params, generator)
 
 		assert.Error(t, err)
 		assert.Nil(t, cvt)
@@ -131,7 +222,7 @@ func TestConverter_EventToOperation(t *testing.T) {
 		AccountID: identifier.Account{
 			Address: "0102030405060708",
 		},
-		Amount: object.Amount{
+		Amount: &object.Amount{
 			Value: "42",
 			Currency: identifier.Currency{
 				Symbol:   dps.FlowSymbol,
@@ -149,7 +240,200 @@ func TestConverter_EventToOperation(t *testing.T) {
 		AccountID: identifier.Account{
 			Address: "0203040506070809",
 		},
-		Amount: object.Amount{
+		Amount: &object.Amount{
+			Value: "-42",
+			Currency: identifier.Currency{
+				Symbol:   dps.FlowSymbol,
+				Decimals: dps.FlowDecimals,
+			},
+		},
+	}
+
+	feesType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(4)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "amount",
+				Type:       cadence.UFix64Type{},
+			},
+			{
+				Identifier: "inclusionEffort",
+				Type:       cadence.UFix64Type{},
+			},
+			{
+				Identifier: "executionEffort",
+				Type:       cadence.UFix64Type{},
+			},
+		},
+	}
+	feesEvent := cadence.NewEvent(
+		[]cadence.Value{
+			cadence.UFix64(42),
+			cadence.UFix64(100000000),
+			cadence.UFix64(1337),
+		},
+	).WithType(feesType)
+	feesEventPayload := json.MustEncode(feesEvent)
+
+	feesNetIndex := uint(3)
+	testFeeOp := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &feesNetIndex,
+		},
+		Type:   configuration.OperationFee,
+		Status: dps.StatusCompleted,
+		Amount: &object.Amount{
+			Value: "-42",
+			Currency: identifier.Currency{
+				Symbol:   dps.FlowSymbol,
+				Decimals: dps.FlowDecimals,
+			},
+		},
+	}
+
+	nodeStakingType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(5)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "nodeID",
+				Type:       cadence.StringType{},
+			},
+			{
+				Identifier: "amount",
+				Type:       cadence.UFix64Type{},
+			},
+		},
+	}
+	nodeStakingEvent := cadence.NewEvent(
+		[]cadence.Value{
+			cadence.String("node"),
+			cadence.UFix64(42),
+		},
+	).WithType(nodeStakingType)
+	nodeStakingEventPayload := json.MustEncode(nodeStakingEvent)
+
+	delegatorStakingType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(6)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "nodeID",
+				Type:       cadence.StringType{},
+			},
+			{
+				Identifier: "delegatorID",
+				Type:       cadence.UInt32Type{},
+			},
+			{
+				Identifier: "amount",
+				Type:       cadence.UFix64Type{},
+			},
+		},
+	}
+	delegatorStakingEvent := cadence.NewEvent(
+		[]cadence.Value{
+			cadence.String("node"),
+			cadence.UInt32(7),
+			cadence.UFix64(42),
+		},
+	).WithType(delegatorStakingType)
+	delegatorStakingEventPayload := json.MustEncode(delegatorStakingEvent)
+
+	stakeNetIndex := uint(4)
+	testStakeOp := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &stakeNetIndex,
+		},
+		Type:   configuration.OperationStake,
+		Status: dps.StatusCompleted,
+		AccountID: identifier.Account{
+			Address: mocks.GenericAddress(0).String(),
+		},
+		Metadata: &object.OperationMetadata{
+			NodeID: "node",
+			Tokens: "42",
+		},
+	}
+
+	delegatorID := uint32(7)
+	testUnstakeOp := testStakeOp
+	testUnstakeOp.Type = configuration.OperationUnstake
+	testUnstakeOp.Metadata = &object.OperationMetadata{
+		NodeID:      "node",
+		DelegatorID: &delegatorID,
+		Tokens:      "42",
+	}
+
+	createdType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(7)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "address",
+				Type:       cadence.AddressType{},
+			},
+		},
+	}
+	createdEvent := cadence.NewEvent(
+		[]cadence.Value{
+			cadence.NewAddress([8]byte{1, 2, 3, 4, 5, 6, 7, 8}),
+		},
+	).WithType(createdType)
+	createdEventPayload := json.MustEncode(createdEvent)
+
+	createdNetIndex := uint(5)
+	testCreatedOp := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &createdNetIndex,
+		},
+		Type:   configuration.OperationCreateAccount,
+		Status: dps.StatusCompleted,
+		AccountID: identifier.Account{
+			Address: "0102030405060708",
+		},
+	}
+
+	supplyType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(8)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "amount",
+				Type:       cadence.UFix64Type{},
+			},
+		},
+	}
+	supplyEvent := cadence.NewEvent(
+		[]cadence.Value{
+			cadence.UFix64(42),
+		},
+	).WithType(supplyType)
+	supplyEventPayload := json.MustEncode(supplyEvent)
+
+	supplyNetIndex := uint(6)
+	testMintOp := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &supplyNetIndex,
+		},
+		Type:   configuration.OperationMint,
+		Status: dps.StatusCompleted,
+		Amount: &object.Amount{
+			Value: "42",
+			Currency: identifier.Currency{
+				Symbol:   dps.FlowSymbol,
+				Decimals: dps.FlowDecimals,
+			},
+		},
+	}
+	testBurnOp := object.Operation{
+		ID: identifier.Operation{
+			NetworkIndex: &supplyNetIndex,
+		},
+		Type:   configuration.OperationBurn,
+		Status: dps.StatusCompleted,
+		Amount: &object.Amount{
 			Value: "-42",
 			Currency: identifier.Currency{
 				Symbol:   dps.FlowSymbol,
@@ -158,6 +442,15 @@ func TestConverter_EventToOperation(t *testing.T) {
 		},
 	}
 
+	testTokenOp := testDepositOp
+	testTokenOp.Amount = &object.Amount{
+		Value: "42",
+		Currency: identifier.Currency{
+			Symbol:   "TEST",
+			Decimals: dps.FlowDecimals,
+		},
+	}
+
 	id, err := flow.HexStringToIdentifier("a4c4194eae1a2dd0de4f4d51a884db4255bf265a40ddd98477a1d60ef45909ec")
 	require.NoError(t, err)
 
@@ -275,6 +568,137 @@ func TestConverter_EventToOperation(t *testing.T) {
 			wantErr:       assert.NoError,
 			wantOperation: &testWithdrawalOp,
 		},
+		{
+			name: "nominal case with event of other token",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(2),
+				Payload:       depositEventPayload,
+				EventIndex:    1,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testTokenOp,
+		},
+		{
+			name: "nominal case with fees event",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(4),
+				Payload:       feesEventPayload,
+				EventIndex:    3,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testFeeOp,
+		},
+		{
+			name: "wrong amount of fields for fees event",
+
+			event: flow.Event{
+				Type:    mocks.GenericEventType(4),
+				Payload: depositEventPayload,
+			},
+
+			wantErr: assert.Error,
+		},
+		{
+			name: "nominal case with node staking event",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(5),
+				Payload:       nodeStakingEventPayload,
+				EventIndex:    4,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testStakeOp,
+		},
+		{
+			name: "nominal case with delegator unstaking event",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(6),
+				Payload:       delegatorStakingEventPayload,
+				EventIndex:    4,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testUnstakeOp,
+		},
+		{
+			name: "missing node ID field for staking event",
+
+			event: flow.Event{
+				Type:    mocks.GenericEventType(5),
+				Payload: feesEventPayload,
+			},
+
+			wantErr: assert.Error,
+		},
+		{
+			name: "nominal case with account creation event",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(7),
+				Payload:       createdEventPayload,
+				EventIndex:    5,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testCreatedOp,
+		},
+		{
+			name: "wrong amount of fields for account creation event",
+
+			event: flow.Event{
+				Type:    mocks.GenericEventType(7),
+				Payload: depositEventPayload,
+			},
+
+			wantErr: assert.Error,
+		},
+		{
+			name: "nominal case with mint event",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(8),
+				Payload:       supplyEventPayload,
+				EventIndex:    6,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testMintOp,
+		},
+		{
+			name: "nominal case with burn event",
+
+			event: flow.Event{
+				TransactionID: id,
+				Type:          mocks.GenericEventType(9),
+				Payload:       supplyEventPayload,
+				EventIndex:    6,
+			},
+
+			wantErr:       assert.NoError,
+			wantOperation: &testBurnOp,
+		},
+		{
+			name: "wrong amount of fields for mint event",
+
+			event: flow.Event{
+				Type:    mocks.GenericEventType(8),
+				Payload: depositEventPayload,
+			},
+
+			wantErr: assert.Error,
+		},
 		{
 			name: "unsupported event type",
 
@@ -337,8 +761,27 @@ func TestConverter_EventToOperation(t *testing.T) {
 			t.Parallel()
 
 			cvt := &Converter{
-				deposit:    mocks.GenericEventType(0),
-				withdrawal: mocks.GenericEventType(1),
+				deposits: map[flow.EventType]string{
+					mocks.GenericEventType(0): dps.FlowSymbol,
+					mocks.GenericEventType(2): "TEST",
+				},
+				withdrawals: map[flow.EventType]string{
+					mocks.GenericEventType(1): dps.FlowSymbol,
+					mocks.GenericEventType(3): "TEST",
+				},
+				mints: map[flow.EventType]string{
+					mocks.GenericEventType(8): dps.FlowSymbol,
+				},
+				burns: map[flow.EventType]string{
+					mocks.GenericEventType(9): dps.FlowSymbol,
+				},
+				fees:    mocks.GenericEventType(4),
+				created: mocks.GenericEventType(7),
+				staking: map[flow.EventType]string{
+					mocks.GenericEventType(5): configuration.OperationStake,
+					mocks.GenericEventType(6): configuration.OperationUnstake,
+				},
+				table: mocks.GenericAddress(0),
 			}
 
 			got, err := cvt.EventToOperation(test.event)
@@ -352,3 +795,94 @@ func TestConverter_EventToOperation(t *testing.T) {
 		})
 	}
 }
+
+func TestConverter_Reconciliation(t *testing.T) {
+
+	// The events of a transaction in which the rewards of a node are paid from
+	// the fees vault into the staking table, before the node operator commits
+	// unstaked tokens and rewards again, and unstakes some other tokens.
+	fees := cadence.NewAddress([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	table := cadence.NewAddress(mocks.GenericAddress(0))
+
+	transferType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(0)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "amount",
+				Type:       cadence.UInt64Type{},
+			},
+			{
+				Identifier: "address",
+				Type:       cadence.AddressType{},
+			},
+		},
+	}
+	transfer := func(amount uint64, address cadence.Address) []byte {
+		event := cadence.NewEvent([]cadence.Value{cadence.NewUInt64(amount), address}).WithType(transferType)
+		return json.MustEncode(event)
+	}
+
+	stakingType := &cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(mocks.GenericEventType(5)),
+		Fields: []cadence.Field{
+			{
+				Identifier: "nodeID",
+				Type:       cadence.StringType{},
+			},
+			{
+				Identifier: "amount",
+				Type:       cadence.UInt64Type{},
+			},
+		},
+	}
+	staking := func(amount uint64) []byte {
+		event := cadence.NewEvent([]cadence.Value{cadence.String("node"), cadence.NewUInt64(amount)}).WithType(stakingType)
+		return json.MustEncode(event)
+	}
+
+	events := []flow.Event{
+		{Type: mocks.GenericEventType(1), Payload: transfer(100, fees), EventIndex: 0},
+		{Type: mocks.GenericEventType(0), Payload: transfer(100, table), EventIndex: 1},
+		{Type: mocks.GenericEventType(10), Payload: staking(100), EventIndex: 2},
+		{Type: mocks.GenericEventType(5), Payload: staking(150), EventIndex: 3},
+		{Type: mocks.GenericEventType(6), Payload: staking(20), EventIndex: 4},
+	}
+
+	cvt := &Converter{
+		deposits: map[flow.EventType]string{
+			mocks.GenericEventType(0): dps.FlowSymbol,
+		},
+		withdrawals: map[flow.EventType]string{
+			mocks.GenericEventType(1): dps.FlowSymbol,
+		},
+		staking: map[flow.EventType]string{
+			mocks.GenericEventType(5):  configuration.OperationStake,
+			mocks.GenericEventType(6):  configuration.OperationUnstake,
+			mocks.GenericEventType(10): configuration.OperationReward,
+		},
+		table: mocks.GenericAddress(0),
+	}
+
+	// The balance changes of the operations have to match the tokens that were
+	// withdrawn from and deposited into the vaults of each account.
+	changes := make(map[string]int64)
+	for _, event := range events {
+		op, err := cvt.EventToOperation(event)
+		require.NoError(t, err)
+
+		if op.Amount == nil {
+			continue
+		}
+		amount, err := strconv.ParseInt(op.Amount.Value, 10, 64)
+		require.NoError(t, err)
+		changes[op.AccountID.Address] += amount
+	}
+
+	want := map[string]int64{
+		flow.Address(fees).String():  -100,
+		flow.Address(table).String(): 100,
+	}
+	assert.Equal(t, want, changes)
+}
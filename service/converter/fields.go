@@ -0,0 +1,35 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package converter
+
+import (
+	"github.com/onflow/cadence"
+)
+
+// fields returns the values of the fields of the given Cadence event, indexed
+// by the name of the field.
+func fields(e cadence.Event) map[string]cadence.Value {
+	values := make(map[string]cadence.Value, len(e.Fields))
+	if e.EventType == nil {
+		return values
+	}
+	for i, field := range e.EventType.Fields {
+		if i >= len(e.Fields) {
+			break
+		}
+		values[field.Identifier] = e.Fields[i]
+	}
+	return values
+}
@@ -14,9 +14,20 @@
 
 package converter
 
-// Generator represents something that can generate scripts for retrieving the amounts
-// deposited and withdrawn for a given token.
+// Generator represents something that can generate the types of the events for
+// token deposits, withdrawals, mints and burns, transaction fees, account creations and staking.
 type Generator interface {
 	TokensDeposited(symbol string) (string, error)
 	TokensWithdrawn(symbol string) (string, error)
+	TokensMinted(symbol string) (string, error)
+	TokensBurned(symbol string) (string, error)
+	FeesDeducted() (string, error)
+	AccountCreated() (string, error)
+
+	TokensCommitted() (string, error)
+	TokensUnstaked() (string, error)
+	RewardsPaid() (string, error)
+	DelegatorTokensCommitted() (string, error)
+	DelegatorTokensUnstaked() (string, error)
+	DelegatorRewardsPaid() (string, error)
 }
@@ -0,0 +1,286 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package events
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Types of the block events in the log. As blocks are only indexed once they
+// are sealed, they are never removed from the chain.
+const (
+	TypeBlockAdded = "block_added"
+)
+
+// prefixEvent is the key prefix for the block events of the log, which are
+// keyed by their big-endian encoded sequence number.
+const prefixEvent = 1
+
+// Log is a persistent log of block events, which is appended to as new blocks
+// are indexed. The sequence number of an event never changes once it has been
+// appended to the log, including across restarts.
+type Log struct {
+	cfg Config
+
+	db    *badger.DB
+	index dps.Reader
+
+	// update makes sure that only one update appends events at a time, while
+	// mutex protects the sequence number and height of the next event.
+	update sync.Mutex
+	mutex  sync.RWMutex
+	next   uint64
+	height uint64
+}
+
+// New creates a new block event log, which is stored in the given database and
+// follows the blocks of the given index. If the database already contains
+// events, the log continues after the last one.
+func New(db *badger.DB, index dps.Reader, options ...func(*Config)) (*Log, error) {
+
+	cfg := Config{
+		Limit:     1000,
+		BatchSize: 1000,
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	l := Log{
+		cfg:   cfg,
+		db:    db,
+		index: index,
+	}
+
+	sequence, height, ok, err := l.last()
+	if err != nil {
+		return nil, fmt.Errorf("could not read last block event: %w", err)
+	}
+	if ok {
+		l.next = sequence + 1
+		l.height = height + 1
+		return &l, nil
+	}
+
+	first, err := index.First()
+	if err != nil {
+		return nil, fmt.Errorf("could not get first height: %w", err)
+	}
+	l.height = first
+
+	return &l, nil
+}
+
+// Update appends an event to the log for each block that was indexed since
+// the last update. Events are committed in batches, so that they can be served
+// while the log catches up with the index, and the update stops between two
+// batches once the given context is canceled.
+func (l *Log) Update(ctx context.Context) error {
+
+	l.update.Lock()
+	defer l.update.Unlock()
+
+	last, err := l.index.Last()
+	if err != nil {
+		return fmt.Errorf("could not get last height: %w", err)
+	}
+
+	l.mutex.RLock()
+	sequence, height := l.next, l.height
+	l.mutex.RUnlock()
+
+	for height <= last {
+
+		err = ctx.Err()
+		if err != nil {
+			return fmt.Errorf("could not finish update: %w", err)
+		}
+
+		end := height + uint64(l.cfg.BatchSize) - 1
+		if end > last {
+			end = last
+		}
+
+		err = l.db.Update(func(tx *badger.Txn) error {
+			for h := height; h <= end; h++ {
+				header, err := l.index.Header(h)
+				if err != nil {
+					return fmt.Errorf("could not get header (height: %d): %w", h, err)
+				}
+				err = tx.Set(eventKey(sequence+h-height), eventValue(h, header.ID()))
+				if err != nil {
+					return fmt.Errorf("could not set block event (height: %d): %w", h, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not append block events: %w", err)
+		}
+
+		sequence += end - height + 1
+		height = end + 1
+
+		l.mutex.Lock()
+		l.next, l.height = sequence, height
+		l.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// Blocks returns up to the given limit of block events, starting with the
+// event with the given sequence number, as well as the highest sequence number
+// of the log. If the limit is zero or above the configured limit, the
+// configured limit is used instead.
+func (l *Log) Blocks(offset uint64, limit uint) ([]object.BlockEvent, uint64, error) {
+
+	l.mutex.RLock()
+	next := l.next
+	l.mutex.RUnlock()
+
+	// The log is empty until the first block has been appended, in which case
+	// there are no events to return.
+	if next == 0 {
+		return []object.BlockEvent{}, 0, nil
+	}
+	max := next - 1
+
+	if offset >= next {
+		return []object.BlockEvent{}, max, nil
+	}
+
+	if limit == 0 || limit > l.cfg.Limit {
+		limit = l.cfg.Limit
+	}
+	end := next
+	if uint64(limit) < next-offset {
+		end = offset + uint64(limit)
+	}
+
+	events := make([]object.BlockEvent, 0, limit)
+	err := l.db.View(func(tx *badger.Txn) error {
+		for sequence := offset; sequence < end; sequence++ {
+			item, err := tx.Get(eventKey(sequence))
+			if err != nil {
+				return fmt.Errorf("could not get block event (sequence: %d): %w", sequence, err)
+			}
+			var height uint64
+			var blockID flow.Identifier
+			err = item.Value(func(val []byte) error {
+				var err error
+				height, blockID, err = decodeEvent(val)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode block event (sequence: %d): %w", sequence, err)
+			}
+			event := object.BlockEvent{
+				Sequence: sequence,
+				BlockID: identifier.Block{
+					Index: &height,
+					Hash:  blockID.String(),
+				},
+				Type: TypeBlockAdded,
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, max, nil
+}
+
+// last returns the sequence number and height of the last event of the log,
+// if there is one.
+func (l *Log) last() (uint64, uint64, bool, error) {
+
+	var sequence, height uint64
+	var ok bool
+	err := l.db.View(func(tx *badger.Txn) error {
+
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		opts.Prefix = []byte{prefixEvent}
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// When iterating in reverse, seeking to the largest possible key of
+		// the prefix gives us the event with the highest sequence number.
+		it.Seek(eventKey(^uint64(0)))
+		if !it.Valid() {
+			return nil
+		}
+
+		item := it.Item()
+		sequence = binary.BigEndian.Uint64(item.Key()[1:])
+		err := item.Value(func(val []byte) error {
+			var err error
+			height, _, err = decodeEvent(val)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		ok = true
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return sequence, height, ok, nil
+}
+
+func eventKey(sequence uint64) []byte {
+	key := make([]byte, 1+8)
+	key[0] = prefixEvent
+	binary.BigEndian.PutUint64(key[1:], sequence)
+	return key
+}
+
+func eventValue(height uint64, blockID flow.Identifier) []byte {
+	val := make([]byte, 8+len(blockID))
+	binary.BigEndian.PutUint64(val[:8], height)
+	copy(val[8:], blockID[:])
+	return val
+}
+
+func decodeEvent(val []byte) (uint64, flow.Identifier, error) {
+	if len(val) != 8+len(flow.ZeroID) {
+		return 0, flow.ZeroID, errors.New("invalid block event length")
+	}
+	height := binary.BigEndian.Uint64(val[:8])
+	var blockID flow.Identifier
+	copy(blockID[:], val[8:])
+	return height, blockID, nil
+}
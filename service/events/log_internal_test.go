@@ -0,0 +1,94 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package events
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestNew(t *testing.T) {
+
+	t.Run("nominal case with empty database", func(t *testing.T) {
+		db := inMemoryDB(t)
+		index := mocks.BaselineReader(t)
+
+		l, err := New(db, index, WithLimit(42), WithBatchSize(84))
+
+		require.NoError(t, err)
+		assert.Equal(t, db, l.db)
+		assert.Equal(t, index, l.index)
+		assert.Equal(t, uint(42), l.cfg.Limit)
+		assert.Equal(t, uint(84), l.cfg.BatchSize)
+		assert.Equal(t, uint64(0), l.next)
+		assert.Equal(t, mocks.GenericHeight, l.height)
+	})
+
+	t.Run("nominal case with existing events", func(t *testing.T) {
+		db := inMemoryDB(t)
+		err := db.Update(func(tx *badger.Txn) error {
+			for sequence := uint64(0); sequence < 3; sequence++ {
+				err := tx.Set(eventKey(sequence), eventValue(100+sequence, mocks.GenericHeader.ID()))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		index := mocks.BaselineReader(t)
+		index.FirstFunc = func() (uint64, error) {
+			t.Fatal("unexpected first height lookup")
+			return 0, nil
+		}
+
+		l, err := New(db, index)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), l.next)
+		assert.Equal(t, uint64(103), l.height)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		index := mocks.BaselineReader(t)
+		index.FirstFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		_, err := New(inMemoryDB(t), index)
+
+		assert.Error(t, err)
+	})
+}
+
+func inMemoryDB(t *testing.T) *badger.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
@@ -0,0 +1,300 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/events"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestLog_Update(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index, last := chain(t, 10, 14)
+
+		l, err := events.New(inMemoryDB(t), index, events.WithBatchSize(2))
+		require.NoError(t, err)
+
+		err = l.Update(context.Background())
+		require.NoError(t, err)
+
+		got, max, err := l.Blocks(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), max)
+		require.Len(t, got, 5)
+		for i, event := range got {
+			height := uint64(10 + i)
+			assert.Equal(t, uint64(i), event.Sequence)
+			assert.Equal(t, events.TypeBlockAdded, event.Type)
+			require.NotNil(t, event.BlockID.Index)
+			assert.Equal(t, height, *event.BlockID.Index)
+			assert.Equal(t, blockID(height).String(), event.BlockID.Hash)
+		}
+
+		// Once the index advances, the new blocks are appended after the
+		// existing events.
+		*last = 16
+		err = l.Update(context.Background())
+		require.NoError(t, err)
+
+		got, max, err = l.Blocks(5, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), max)
+		require.Len(t, got, 2)
+		assert.Equal(t, uint64(5), got[0].Sequence)
+		assert.Equal(t, uint64(15), *got[0].BlockID.Index)
+		assert.Equal(t, uint64(6), got[1].Sequence)
+		assert.Equal(t, uint64(16), *got[1].BlockID.Index)
+	})
+
+	t.Run("nominal case without new blocks", func(t *testing.T) {
+		t.Parallel()
+
+		index, _ := chain(t, 10, 12)
+
+		l, err := events.New(inMemoryDB(t), index)
+		require.NoError(t, err)
+
+		require.NoError(t, l.Update(context.Background()))
+		require.NoError(t, l.Update(context.Background()))
+
+		got, max, err := l.Blocks(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), max)
+		assert.Len(t, got, 3)
+	})
+
+	t.Run("keeps sequence numbers across restarts", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		index, last := chain(t, 10, 12)
+
+		db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		require.NoError(t, err)
+		l, err := events.New(db, index)
+		require.NoError(t, err)
+		require.NoError(t, l.Update(context.Background()))
+		require.NoError(t, db.Close())
+
+		*last = 14
+		db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		l, err = events.New(db, index)
+		require.NoError(t, err)
+		require.NoError(t, l.Update(context.Background()))
+
+		got, max, err := l.Blocks(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), max)
+		require.Len(t, got, 5)
+		for i, event := range got {
+			assert.Equal(t, uint64(i), event.Sequence)
+			assert.Equal(t, uint64(10+i), *event.BlockID.Index)
+		}
+	})
+
+	t.Run("stops between batches when canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		index, _ := chain(t, 10, 14)
+		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			if height == 11 {
+				cancel()
+			}
+			return &flow.Header{Height: height}, nil
+		}
+
+		l, err := events.New(inMemoryDB(t), index, events.WithBatchSize(2))
+		require.NoError(t, err)
+
+		err = l.Update(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// The batch that was in progress when the context was canceled is
+		// still committed, and the update resumes after it.
+		got, max, err := l.Blocks(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), max)
+		assert.Len(t, got, 2)
+
+		err = l.Update(context.Background())
+		require.NoError(t, err)
+
+		got, max, err = l.Blocks(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), max)
+		assert.Len(t, got, 5)
+	})
+
+	t.Run("handles index failure on Last", func(t *testing.T) {
+		t.Parallel()
+
+		index, _ := chain(t, 10, 12)
+		index.LastFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		l, err := events.New(inMemoryDB(t), index)
+		require.NoError(t, err)
+
+		err = l.Update(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failure on Header", func(t *testing.T) {
+		t.Parallel()
+
+		index, _ := chain(t, 10, 14)
+		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			if height == 13 {
+				return nil, mocks.GenericError
+			}
+			return &flow.Header{Height: height}, nil
+		}
+
+		l, err := events.New(inMemoryDB(t), index, events.WithBatchSize(2))
+		require.NoError(t, err)
+
+		err = l.Update(context.Background())
+		assert.Error(t, err)
+
+		// The batches before the failure are kept, while the failed batch is
+		// retried on the next update.
+		got, max, err := l.Blocks(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), max)
+		assert.Len(t, got, 2)
+	})
+}
+
+func TestLog_Blocks(t *testing.T) {
+
+	index, _ := chain(t, 10, 19)
+
+	l, err := events.New(inMemoryDB(t), index, events.WithLimit(4))
+	require.NoError(t, err)
+	require.NoError(t, l.Update(context.Background()))
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got, max, err := l.Blocks(2, 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(9), max)
+		require.Len(t, got, 3)
+		assert.Equal(t, uint64(2), got[0].Sequence)
+		assert.Equal(t, uint64(4), got[2].Sequence)
+	})
+
+	t.Run("caps limit", func(t *testing.T) {
+		t.Parallel()
+
+		got, _, err := l.Blocks(0, 100)
+
+		require.NoError(t, err)
+		assert.Len(t, got, 4)
+	})
+
+	t.Run("returns partial last page", func(t *testing.T) {
+		t.Parallel()
+
+		got, _, err := l.Blocks(8, 4)
+
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, uint64(9), got[1].Sequence)
+	})
+
+	t.Run("returns empty page beyond last event", func(t *testing.T) {
+		t.Parallel()
+
+		got, max, err := l.Blocks(10, 4)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(9), max)
+		assert.Empty(t, got)
+	})
+
+	t.Run("handles empty log", func(t *testing.T) {
+		t.Parallel()
+
+		index, _ := chain(t, 10, 12)
+		l, err := events.New(inMemoryDB(t), index)
+		require.NoError(t, err)
+
+		got, max, err := l.Blocks(0, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), max)
+		assert.NotNil(t, got)
+		assert.Empty(t, got)
+	})
+}
+
+// chain returns an index with the given range of heights, where the last
+// height can be changed through the returned pointer.
+func chain(t *testing.T, first uint64, last uint64) (*mocks.Reader, *uint64) {
+	t.Helper()
+
+	index := mocks.BaselineReader(t)
+	index.FirstFunc = func() (uint64, error) {
+		return first, nil
+	}
+	index.LastFunc = func() (uint64, error) {
+		return last, nil
+	}
+	index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+		return &flow.Header{Height: height}, nil
+	}
+
+	return index, &last
+}
+
+func blockID(height uint64) flow.Identifier {
+	header := flow.Header{Height: height}
+	return header.ID()
+}
+
+func inMemoryDB(t *testing.T) *badger.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
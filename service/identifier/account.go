@@ -14,9 +14,10 @@
 
 package identifier
 
-// Account uniquely identifies an account within a network. No sub-accounts are used
-// in this implementation for now, though they will probably have to be added to support
-// staking on Coinbase in the future.
+// Account uniquely identifies an account within a network. The optional sub-account
+// identifies a part of the holdings of the account, such as its locked, staked or
+// delegated tokens, or the tokens reserved for its storage.
 type Account struct {
-	Address string `json:"address"`
+	Address    string      `json:"address"`
+	SubAccount *SubAccount `json:"sub_account,omitempty"`
 }
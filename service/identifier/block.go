@@ -16,7 +16,12 @@ package identifier
 
 // Block uniquely identifies a block in a particular network. As the view is not
 // unique between sporks, index refers to the block height.
+//
+// The timestamp is a non-standard field, which can be used instead of the index
+// and the hash to look up the last block at or before the given time, as a Unix
+// timestamp in milliseconds. It is never set on returned block identifiers.
 type Block struct {
-	Index *uint64 `json:"index,omitempty"`
-	Hash  string  `json:"hash,omitempty"`
+	Index     *uint64 `json:"index,omitempty"`
+	Hash      string  `json:"hash,omitempty"`
+	Timestamp *int64  `json:"timestamp,omitempty"`
 }
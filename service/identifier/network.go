@@ -15,8 +15,8 @@
 package identifier
 
 // Network specifies which network a particular object is associated with. The
-// blockchain field is always set to `flow` and the network is always set to
-// `mainnet`.
+// blockchain field is always set to `flow` and the network is set to the chain
+// ID of one of the served networks, such as `flow-mainnet`.
 //
 // We are omitting the `SubNetwork` field for now, but we could use it in the
 // future to distinguish between the networks of different sporks (i.e.
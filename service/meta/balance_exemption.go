@@ -0,0 +1,29 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package meta
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// BalanceExemption indicates that the balance of an account for a given
+// currency can change without a corresponding operation, so that its balance
+// cannot be reconciled from the operations alone. The exemption type specifies
+// in which direction the balance can diverge.
+type BalanceExemption struct {
+	Account       identifier.Account  `json:"account_identifier"`
+	Currency      identifier.Currency `json:"currency"`
+	ExemptionType string              `json:"exemption_type"`
+}
@@ -31,4 +31,5 @@ type Block struct {
 	ParentID     identifier.Block `json:"parent_block_identifier"`
 	Timestamp    int64            `json:"timestamp"`
 	Transactions []*Transaction   `json:"transactions"`
+	Metadata     *BlockMetadata   `json:"metadata,omitempty"`
 }
@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package object
+
+// BlockMetadata contains additional information about a block, as found in
+// the Flow block header and payload, as well as the execution state
+// commitment after the block was executed.
+type BlockMetadata struct {
+	ProposerID      string      `json:"proposer_id"`
+	View            uint64      `json:"view"`
+	StateCommitment string      `json:"state_commitment"`
+	Guarantees      []Guarantee `json:"collection_guarantees"`
+	Seals           []Seal      `json:"seals"`
+}
+
+// Guarantee is a collection guarantee included in a block.
+type Guarantee struct {
+	CollectionID     string   `json:"collection_id"`
+	ReferenceBlockID string   `json:"reference_block_id"`
+	SignerIDs        []string `json:"signer_ids"`
+}
+
+// Seal is a block seal included in a block, which seals the execution result
+// of a previous block.
+type Seal struct {
+	BlockID    string `json:"block_id"`
+	ResultID   string `json:"result_id"`
+	FinalState string `json:"final_state"`
+}
@@ -26,14 +26,24 @@ import (
 // blockchains.
 //
 // Examples of metadata given in the Rosetta API documentation are
-// "asm" and "hex".
+// "asm" and "hex". Here, metadata is used to give context to operations that
+// do not directly move tokens between accounts, such as staking operations.
+//
+// Related operations link the withdrawal of tokens to the deposits of the same
+// tokens within a transaction. As required by the Rosetta API specification,
+// they only reference operations with a lower index.
+//
+// The amount is optional, as some operations, such as account creations, do
+// not change any balance.
 //
 // The `coin_change` field is omitted, as the Flow blockchain is an
 // account-based blockchain without utxo set.
 type Operation struct {
-	ID        identifier.Operation `json:"operation_identifier"`
-	Type      string               `json:"type"`
-	Status    string               `json:"status,omitempty"`
-	AccountID identifier.Account   `json:"account"`
-	Amount    Amount               `json:"amount"`
+	ID         identifier.Operation   `json:"operation_identifier"`
+	RelatedIDs []identifier.Operation `json:"related_operations,omitempty"`
+	Type       string                 `json:"type"`
+	Status     string                 `json:"status,omitempty"`
+	AccountID  identifier.Account     `json:"account"`
+	Amount     *Amount                `json:"amount,omitempty"`
+	Metadata   *OperationMetadata     `json:"metadata,omitempty"`
 }
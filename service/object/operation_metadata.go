@@ -0,0 +1,29 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package object
+
+// OperationMetadata contains additional information about an operation that
+// can not be expressed through its account and amount.
+//
+// For staking operations, it contains the ID of the node and, in the case of
+// a delegation, the ID of the delegator, as well as the amount of tokens that
+// were committed, unstaked or rewarded. For account creations, it contains the
+// address of the payer of the transaction that created the account.
+type OperationMetadata struct {
+	NodeID      string  `json:"node_id,omitempty"`
+	DelegatorID *uint32 `json:"delegator_id,omitempty"`
+	Tokens      string  `json:"tokens,omitempty"`
+	Payer       string  `json:"payer,omitempty"`
+}
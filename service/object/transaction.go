@@ -26,4 +26,5 @@ import (
 type Transaction struct {
 	ID         identifier.Transaction `json:"transaction_identifier"`
 	Operations []*Operation           `json:"operations"`
+	Metadata   *TransactionMetadata   `json:"metadata,omitempty"`
 }
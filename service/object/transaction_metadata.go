@@ -0,0 +1,35 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package object
+
+// TransactionMetadata contains additional information about a transaction, as
+// found in the Flow transaction body and its result.
+//
+// The collection ID is omitted for the system chunk transaction, which is not
+// part of any collection. For transactions that failed during execution, the
+// error message returned by the Cadence runtime is included.
+type TransactionMetadata struct {
+	Payer            string   `json:"payer"`
+	Proposer         string   `json:"proposer"`
+	ProposalKeyIndex uint64   `json:"proposal_key_index"`
+	SequenceNumber   uint64   `json:"sequence_number"`
+	Authorizers      []string `json:"authorizers"`
+	GasLimit         uint64   `json:"gas_limit"`
+	ComputationUsed  uint64   `json:"computation_used"`
+	ReferenceBlockID string   `json:"reference_block_id"`
+	CollectionID     string   `json:"collection_id,omitempty"`
+	ScriptHash       string   `json:"script_hash"`
+	ErrorMessage     string   `json:"error_message,omitempty"`
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package request
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// BalanceHistory implements the request schema for the non-standard
+// /account/balance/history endpoint, which returns the balance of an account
+// for a currency over a range of blocks.
+type BalanceHistory struct {
+	NetworkID    identifier.Network  `json:"network_identifier"`
+	AccountID    identifier.Account  `json:"account_identifier"`
+	Currency     identifier.Currency `json:"currency"`
+	StartBlockID identifier.Block    `json:"start_block_identifier"`
+	EndBlockID   identifier.Block    `json:"end_block_identifier"`
+}
@@ -0,0 +1,29 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package request
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// EventsBlocks implements the request schema for /events/blocks. The offset is
+// the sequence number of the first event to return, and the limit is the
+// maximum number of events to return.
+// See https://www.rosetta-api.org/docs/EventsApi.html#request
+type EventsBlocks struct {
+	NetworkID identifier.Network `json:"network_identifier"`
+	Offset    uint64             `json:"offset,omitempty"`
+	Limit     uint               `json:"limit,omitempty"`
+}
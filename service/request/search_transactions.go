@@ -0,0 +1,37 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package request
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// SearchTransactions implements the request schema for /search/transactions.
+// All of the given filters have to match, so the only supported operator is
+// `and`, which is also the default. The offset is the number of matching
+// transactions to skip, and the limit is the maximum number of transactions to
+// return.
+// See https://www.rosetta-api.org/docs/SearchApi.html#request
+type SearchTransactions struct {
+	NetworkID     identifier.Network      `json:"network_identifier"`
+	Operator      string                  `json:"operator,omitempty"`
+	MaxBlock      *uint64                 `json:"max_block,omitempty"`
+	Offset        uint                    `json:"offset,omitempty"`
+	Limit         uint                    `json:"limit,omitempty"`
+	TransactionID *identifier.Transaction `json:"transaction_identifier,omitempty"`
+	AccountID     *identifier.Account     `json:"account_identifier,omitempty"`
+	Type          string                  `json:"type,omitempty"`
+	Success       *bool                   `json:"success,omitempty"`
+}
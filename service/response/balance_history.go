@@ -0,0 +1,30 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package response
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
+)
+
+// BalanceHistory implements the response schema for the non-standard
+// /account/balance/history endpoint. The first balance is the one at the start
+// of the requested range, and every following balance is the one at a block
+// where the balance changed.
+type BalanceHistory struct {
+	AccountID identifier.Account    `json:"account_identifier"`
+	Currency  identifier.Currency   `json:"currency"`
+	Balances  []object.BalancePoint `json:"balances"`
+}
@@ -25,14 +25,14 @@ type Options struct {
 	Allow   OptionsAllow `json:"allow"`
 }
 
-// OptionsAllow specifies supported Operation statuses, Operation types, and all possible
-// error statuses. It is returned by the /network/options endpoint.
+// OptionsAllow specifies supported Operation statuses, Operation types, all possible
+// error statuses and the balance exemptions. It is returned by the /network/options endpoint.
 type OptionsAllow struct {
 	OperationStatuses       []meta.StatusDefinition `json:"operation_statuses"`
 	OperationTypes          []string                `json:"operation_types"`
 	Errors                  []meta.ErrorDefinition  `json:"errors"`
 	HistoricalBalanceLookup bool                    `json:"historical_balance_lookup"`
-	CallMethods             []string                `json:"call_methods"`       // not used
-	BalanceExemptions       []struct{}              `json:"balance_exemptions"` // not used
+	CallMethods             []string                `json:"call_methods"` // not used
+	BalanceExemptions       []meta.BalanceExemption `json:"balance_exemptions"`
 	MempoolCoins            bool                    `json:"mempool_coins"`
 }
@@ -16,14 +16,16 @@ package response
 
 import (
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
 )
 
 // Status implements the successful response schema for /network/status.
 // See https://www.rosetta-api.org/docs/NetworkApi.html#200---ok-2
 type Status struct {
-	CurrentBlockID        identifier.Block `json:"current_block_identifier"`
-	CurrentBlockTimestamp int64            `json:"current_block_timestamp"`
-	OldestBlockID         identifier.Block `json:"oldest_block_identifier"`
-	GenesisBlockID        identifier.Block `json:"genesis_block_identifier"`
-	Peers                 []struct{}       `json:"peers"` // not used
+	CurrentBlockID        identifier.Block   `json:"current_block_identifier"`
+	CurrentBlockTimestamp int64              `json:"current_block_timestamp"`
+	OldestBlockID         identifier.Block   `json:"oldest_block_identifier"`
+	GenesisBlockID        identifier.Block   `json:"genesis_block_identifier"`
+	SyncStatus            *object.SyncStatus `json:"sync_status,omitempty"`
+	Peers                 []object.Peer      `json:"peers"`
 }
@@ -0,0 +1,21 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package retriever
+
+// Cache represents something that caches values which never change once they have
+// been loaded, and that loads each missing value only once for concurrent requests.
+type Cache interface {
+	Get(key string, load func() (interface{}, error)) (interface{}, error)
+}
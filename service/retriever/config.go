@@ -14,9 +14,17 @@
 
 package retriever
 
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
 // Config is the configuration for the Rosetta retriever component.
 type Config struct {
 	TransactionLimit uint
+	HistoryLimit     uint64
+	Workers          uint
+	Previous         *identifier.Block
+	Genesis          *identifier.Block
 }
 
 // WithTransactionLimit sets a transaction limit in a Config.
@@ -25,3 +33,33 @@ func WithTransactionLimit(limit uint) func(*Config) {
 		c.TransactionLimit = limit
 	}
 }
+
+// WithHistoryLimit sets the maximum number of blocks in a balance history range in a Config.
+func WithHistoryLimit(limit uint64) func(*Config) {
+	return func(c *Config) {
+		c.HistoryLimit = limit
+	}
+}
+
+// WithWorkers sets the maximum number of transactions of a block that are built concurrently in a Config.
+func WithWorkers(workers uint) func(*Config) {
+	return func(c *Config) {
+		c.Workers = workers
+	}
+}
+
+// WithPrevious sets the identifier of the last block of the previous spork in a Config,
+// which is used as the parent of the first indexed block.
+func WithPrevious(previous identifier.Block) func(*Config) {
+	return func(c *Config) {
+		c.Previous = &previous
+	}
+}
+
+// WithGenesis sets the identifier of the genesis block of the network in a Config,
+// for when it is not the first indexed block.
+func WithGenesis(genesis identifier.Block) func(*Config) {
+	return func(c *Config) {
+		c.Genesis = &genesis
+	}
+}
@@ -15,9 +15,13 @@
 package retriever
 
 import (
+	"encoding/hex"
+
+	"github.com/onflow/flow-go/crypto/hash"
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
 )
 
 func rosettaTxID(txID flow.Identifier) identifier.Transaction {
@@ -39,3 +43,57 @@ func rosettaCurrency(symbol string, decimals uint) identifier.Currency {
 		Decimals: decimals,
 	}
 }
+
+func rosettaTxMetadata(tx *flow.TransactionBody, result *flow.TransactionResult, collID flow.Identifier) *object.TransactionMetadata {
+
+	authorizers := make([]string, 0, len(tx.Authorizers))
+	for _, authorizer := range tx.Authorizers {
+		authorizers = append(authorizers, authorizer.String())
+	}
+
+	var collection string
+	if collID != flow.ZeroID {
+		collection = collID.String()
+	}
+
+	hasher := hash.NewSHA3_256()
+	scriptHash := hasher.ComputeHash(tx.Script)
+
+	metadata := object.TransactionMetadata{
+		Payer:            tx.Payer.String(),
+		Proposer:         tx.ProposalKey.Address.String(),
+		ProposalKeyIndex: tx.ProposalKey.KeyIndex,
+		SequenceNumber:   tx.ProposalKey.SequenceNumber,
+		Authorizers:      authorizers,
+		GasLimit:         tx.GasLimit,
+		ComputationUsed:  result.ComputationUsed,
+		ReferenceBlockID: tx.ReferenceBlockID.String(),
+		CollectionID:     collection,
+		ScriptHash:       hex.EncodeToString(scriptHash),
+		ErrorMessage:     result.ErrorMessage,
+	}
+
+	return &metadata
+}
+
+func rosettaGuarantee(guarantee *flow.CollectionGuarantee) object.Guarantee {
+
+	signerIDs := make([]string, 0, len(guarantee.SignerIDs))
+	for _, signerID := range guarantee.SignerIDs {
+		signerIDs = append(signerIDs, signerID.String())
+	}
+
+	return object.Guarantee{
+		CollectionID:     guarantee.CollectionID.String(),
+		ReferenceBlockID: guarantee.ReferenceBlockID.String(),
+		SignerIDs:        signerIDs,
+	}
+}
+
+func rosettaSeal(seal *flow.Seal) object.Seal {
+	return object.Seal{
+		BlockID:    seal.BlockID.String(),
+		ResultID:   seal.ResultID.String(),
+		FinalState: hex.EncodeToString(seal.FinalState[:]),
+	}
+}
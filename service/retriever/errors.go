@@ -17,4 +17,15 @@ const (
 
 	// Error description for failure to find a transaction.
 	txMissing = "transaction not found in given block"
+
+	// Error description for sub-account balances in other currencies than the
+	// native Flow token.
+	subAccountCurrency = "sub-account balances are only available for the native Flow token"
+
+	// Error description for balance history requests on sub-accounts.
+	subAccountHistory = "balance history is not available for sub-accounts"
+
+	// Error descriptions for invalid balance history ranges.
+	rangeInverted = "start block is after end block"
+	rangeExceeded = "block range exceeds history limit"
 )
@@ -15,9 +15,27 @@
 package retriever
 
 // Generator represents something that can generate scripts for retrieving
-// balances as well as the amounts deposited and withdrawn for a given token.
+// balances, sub-account balances and staked nodes, as well as the types of the events for token deposits,
+// withdrawals, mints and burns, transaction fees, account creations and staking.
 type Generator interface {
 	GetBalance(symbol string) ([]byte, error)
+	GetBalances(symbols []string) ([]byte, error)
+	GetLockedBalance() ([]byte, error)
+	GetStakedBalance() ([]byte, error)
+	GetDelegatedBalance() ([]byte, error)
+	GetStorageReserved() ([]byte, error)
+	GetStakedNodes() ([]byte, error)
 	TokensDeposited(symbol string) (string, error)
 	TokensWithdrawn(symbol string) (string, error)
+	TokensMinted(symbol string) (string, error)
+	TokensBurned(symbol string) (string, error)
+	FeesDeducted() (string, error)
+	AccountCreated() (string, error)
+
+	TokensCommitted() (string, error)
+	TokensUnstaked() (string, error)
+	RewardsPaid() (string, error)
+	DelegatorTokensCommitted() (string, error)
+	DelegatorTokensUnstaked() (string, error)
+	DelegatorRewardsPaid() (string, error)
 }
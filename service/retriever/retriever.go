@@ -15,16 +15,20 @@
 package retriever
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/failure"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/service/object"
@@ -41,14 +45,24 @@ type Retriever struct {
 	validate Validator
 	generate Generator
 	invoke   Invoker
+	vault    Vault
 	convert  Converter
+	cache    Cache
+
+	// peerMutex protects the staked nodes cached for the last height at which
+	// they were retrieved.
+	peerMutex  sync.Mutex
+	peerHeight uint64
+	peers      []object.Peer
 }
 
 // New instantiates and returns a Retriever using the injected dependencies, as well as the provided options.
-func New(params dps.Params, index dps.Reader, validate Validator, generator Generator, invoke Invoker, convert Converter, options ...func(*Config)) *Retriever {
+func New(params dps.Params, index dps.Reader, validate Validator, generator Generator, invoke Invoker, vault Vault, convert Converter, cache Cache, options ...func(*Config)) *Retriever {
 
 	cfg := Config{
 		TransactionLimit: 200,
+		HistoryLimit:     1000,
+		Workers:          uint(runtime.NumCPU()),
 	}
 
 	for _, opt := range options {
@@ -62,7 +76,9 @@ func New(params dps.Params, index dps.Reader, validate Validator, generator Gene
 		validate: validate,
 		generate: generator,
 		invoke:   invoke,
+		vault:    vault,
 		convert:  convert,
+		cache:    cache,
 	}
 
 	return &r
@@ -89,6 +105,22 @@ func (r *Retriever) Oldest() (identifier.Block, time.Time, error) {
 	return block, header.Timestamp, nil
 }
 
+// Genesis retrieves the genesis block identifier. It is the configured genesis
+// block if there is one, and the oldest block otherwise.
+func (r *Retriever) Genesis() (identifier.Block, error) {
+
+	if r.cfg.Genesis != nil {
+		return *r.cfg.Genesis, nil
+	}
+
+	oldest, _, err := r.Oldest()
+	if err != nil {
+		return identifier.Block{}, fmt.Errorf("could not retrieve oldest block: %w", err)
+	}
+
+	return oldest, nil
+}
+
 // Current retrieves the last block identifier as well as its timestamp.
 func (r *Retriever) Current() (identifier.Block, time.Time, error) {
 
@@ -110,6 +142,100 @@ func (r *Retriever) Current() (identifier.Block, time.Time, error) {
 	return block, header.Timestamp, nil
 }
 
+// Peers retrieves the nodes that are staked at the last indexed height. They are
+// cached until a new block is indexed, so that frequent status requests do not
+// execute the staking table script every time.
+func (r *Retriever) Peers() ([]object.Peer, error) {
+
+	last, err := r.index.Last()
+	if err != nil {
+		return nil, fmt.Errorf("could not find last indexed block: %w", err)
+	}
+
+	r.peerMutex.Lock()
+	defer r.peerMutex.Unlock()
+
+	if r.peers != nil && r.peerHeight == last {
+		return r.peers, nil
+	}
+
+	peers, err := r.stakedNodes(last)
+	if err != nil {
+		return nil, err
+	}
+
+	r.peerHeight = last
+	r.peers = peers
+
+	return peers, nil
+}
+
+// stakedNodes retrieves the nodes that are staked at the given height.
+func (r *Retriever) stakedNodes(height uint64) ([]object.Peer, error) {
+
+	script, err := r.generate.GetStakedNodes()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate script: %w", err)
+	}
+
+	result, err := r.invoke.Script(height, script, []cadence.Value{})
+	if err != nil {
+		return nil, fmt.Errorf("could not invoke script: %w", err)
+	}
+
+	nodes, ok := result.(cadence.Array)
+	if !ok {
+		return nil, fmt.Errorf("unexpected script result type (got: %T, want cadence.Array)", result)
+	}
+
+	peers := make([]object.Peer, 0, len(nodes.Values))
+	for _, value := range nodes.Values {
+		node, ok := value.(cadence.Struct)
+		if !ok || node.StructType == nil {
+			return nil, fmt.Errorf("unexpected node info type (got: %T, want cadence.Struct)", value)
+		}
+
+		// The node info structure has many fields, so we look up the ones we
+		// need by their identifier, rather than relying on their order.
+		fields := make(map[string]cadence.Value, len(node.Fields))
+		for index, field := range node.StructType.Fields {
+			if index >= len(node.Fields) {
+				break
+			}
+			fields[field.Identifier] = node.Fields[index]
+		}
+
+		nodeID, ok := fields["id"].(cadence.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid node ID field (%v)", fields["id"])
+		}
+		address, ok := fields["networkingAddress"].(cadence.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid networking address field (%v)", fields["networkingAddress"])
+		}
+		number, ok := fields["role"].(cadence.UInt8)
+		if !ok {
+			return nil, fmt.Errorf("invalid role field (%v)", fields["role"])
+		}
+
+		role := flow.Role(number)
+		if !role.Valid() {
+			return nil, fmt.Errorf("invalid node role (%d)", number)
+		}
+
+		peer := object.Peer{
+			ID: string(nodeID),
+			Metadata: object.PeerMetadata{
+				Role:              role.String(),
+				NetworkingAddress: string(address),
+			},
+		}
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
 // Balances retrieves the balances for the given currencies of the given account ID at the given block.
 func (r *Retriever) Balances(rosBlockID identifier.Block, rosAccountID identifier.Account, rosCurrencies []identifier.Currency) (identifier.Block, []object.Amount, error) {
 
@@ -140,42 +266,225 @@ func (r *Retriever) Balances(rosBlockID identifier.Block, rosAccountID identifie
 		decimals[symbol] = decimal
 	}
 
-	// Get the Cadence value that is the result of the script execution.
+	// The balances of an account at a sealed height never change, so we only
+	// retrieve them once for each account, sub-account and set of currencies.
+	sub := ""
+	if rosAccountID.SubAccount != nil {
+		sub = rosAccountID.SubAccount.Address
+	}
+	key := fmt.Sprintf("balances/%d/%s/%s/%s", height, address, sub, strings.Join(symbols, ","))
+	value, err := r.cache.Get(key, func() (interface{}, error) {
+		if rosAccountID.SubAccount == nil {
+			return r.vaults(height, address, symbols)
+		}
+		return r.subAccounts(height, address, rosAccountID.SubAccount, symbols)
+	})
+	if err != nil {
+		return identifier.Block{}, nil, fmt.Errorf("could not get balances: %w", err)
+	}
+	balances := value.(map[string]uint64)
+
 	amounts := make([]object.Amount, 0, len(symbols))
 	for _, symbol := range symbols {
+		amount := object.Amount{
+			Currency: rosettaCurrency(symbol, decimals[symbol]),
+			Value:    strconv.FormatUint(balances[symbol], 10),
+		}
+		amounts = append(amounts, amount)
+	}
 
-		// We generate the script to get the vault balance and execute it.
-		script, err := r.generate.GetBalance(symbol)
+	return rosettaBlockID(height, blockID), amounts, nil
+}
+
+// BalanceHistory retrieves the balances of the given currency for the given account
+// over the given range of blocks. It returns the balance at the start of the range,
+// followed by the balance at every block within the range where it changed. Heights
+// where the balance might have changed are identified using the indexed deposit and
+// withdrawal events, so that the balance script is only executed for those heights.
+// The range can span at most the configured history limit, and the events of its
+// heights are retrieved concurrently by a bounded number of workers.
+func (r *Retriever) BalanceHistory(rosAccountID identifier.Account, rosCurrency identifier.Currency, rosStartID identifier.Block, rosEndID identifier.Block) ([]object.BalancePoint, error) {
+
+	// Run validation on the account qualifier. If it is valid, this will return
+	// the associated Flow account address.
+	address, err := r.validate.Account(rosAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("could not validate account: %w", err)
+	}
+
+	// Sub-account balances can change without any deposit or withdrawal events,
+	// so we can only provide the history of the main vault.
+	if rosAccountID.SubAccount != nil {
+		return nil, failure.InvalidAccount{
+			Address: rosAccountID.Address,
+			Description: failure.NewDescription(subAccountHistory,
+				failure.WithString("sub_account", rosAccountID.SubAccount.Address),
+			),
+		}
+	}
+
+	// Run validation on the currency qualifier. If it is valid, this will
+	// return the associated currency symbol.
+	symbol, _, err := r.validate.Currency(rosCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("could not validate currency: %w", err)
+	}
+
+	// Run validation on the Rosetta block identifiers of both ends of the range.
+	start, startID, err := r.validate.Block(rosStartID)
+	if err != nil {
+		return nil, fmt.Errorf("could not validate start block: %w", err)
+	}
+	end, _, err := r.validate.Block(rosEndID)
+	if err != nil {
+		return nil, fmt.Errorf("could not validate end block: %w", err)
+	}
+	if start > end {
+		return nil, failure.InvalidBlock{
+			Description: failure.NewDescription(rangeInverted,
+				failure.WithUint64("start", start),
+				failure.WithUint64("end", end),
+			),
+		}
+	}
+	if end-start >= r.cfg.HistoryLimit {
+		return nil, failure.InvalidBlock{
+			Description: failure.NewDescription(rangeExceeded,
+				failure.WithUint64("start", start),
+				failure.WithUint64("end", end),
+				failure.WithUint64("limit", r.cfg.HistoryLimit),
+			),
+		}
+	}
+
+	// Only deposits to and withdrawals from the account can change its balance
+	// for the given currency, so those are the only events we need to look at.
+	deposit, err := r.generate.TokensDeposited(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate deposit event type: %w", err)
+	}
+	withdrawal, err := r.generate.TokensWithdrawn(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate withdrawal event type: %w", err)
+	}
+	types := []flow.EventType{flow.EventType(withdrawal), flow.EventType(deposit)}
+
+	// The first point of the history is always the balance at the start of the range.
+	script, err := r.generate.GetBalance(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate script: %w", err)
+	}
+	balance, err := r.tokens(start, address, symbol, script)
+	if err != nil {
+		return nil, fmt.Errorf("could not get start balance: %w", err)
+	}
+	points := []object.BalancePoint{{
+		BlockID: rosettaBlockID(start, startID),
+		Value:   strconv.FormatUint(balance, 10),
+	}}
+
+	heights, err := r.changes(start+1, end, address, types)
+	if err != nil {
+		return nil, fmt.Errorf("could not get balance changes: %w", err)
+	}
+
+	for _, height := range heights {
+
+		// A deposit and a withdrawal of the same amount could cancel each other
+		// out, in which case there is no new point in the history.
+		current, err := r.tokens(height, address, symbol, script)
 		if err != nil {
-			return identifier.Block{}, nil, fmt.Errorf("could not generate script: %w", err)
+			return nil, fmt.Errorf("could not get balance: %w", err)
 		}
-		params := []cadence.Value{cadence.NewAddress(address)}
-		result, err := r.invoke.Script(height, script, params)
-		if err != nil && !strings.Contains(err.Error(), missingVault) {
-			return identifier.Block{}, nil, fmt.Errorf("could not invoke script: %w", err)
+		if current == balance {
+			continue
 		}
+		balance = current
 
-		// In the previous error check, we exclude errors that are about getting
-		// the vault reference in Cadence. In those cases, we keep the default
-		// balance here, which is zero.
-		balance := uint64(0)
-		if err == nil {
-			var ok bool
-			balance, ok = result.ToGoValue().(uint64)
-			if !ok {
-				return identifier.Block{}, nil, fmt.Errorf("unexpected script result type (got: %s, want uint64)", result.String())
-			}
+		header, err := r.index.Header(height)
+		if err != nil {
+			return nil, fmt.Errorf("could not get header: %w", err)
 		}
 
-		amount := object.Amount{
-			Currency: rosettaCurrency(symbol, decimals[symbol]),
-			Value:    strconv.FormatUint(balance, 10),
+		point := object.BalancePoint{
+			BlockID: rosettaBlockID(height, header.ID()),
+			Value:   strconv.FormatUint(balance, 10),
 		}
+		points = append(points, point)
+	}
 
-		amounts = append(amounts, amount)
+	return points, nil
+}
+
+// changes returns the heights within the given range, in ascending order, at which the given account was involved
+// in events of the given types. The index serves events per height, so the heights are looked up concurrently by
+// a bounded number of workers.
+func (r *Retriever) changes(start uint64, end uint64, address flow.Address, types []flow.EventType) ([]uint64, error) {
+
+	if start > end {
+		return nil, nil
 	}
+	count := int(end - start + 1)
 
-	return rosettaBlockID(height, blockID), amounts, nil
+	workers := int(r.cfg.Workers)
+	if workers > count {
+		workers = count
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Each worker checks the events for the positions it receives, and marks
+	// whether the account was involved in them, or the failure to check them.
+	changed := make([]bool, count)
+	errs := make([]error, count)
+	positions := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for position := range positions {
+				height := start + uint64(position)
+				events, err := r.index.Events(height, types...)
+				if err != nil {
+					errs[position] = fmt.Errorf("could not get events (height: %d): %w", height, err)
+					continue
+				}
+				changed[position], err = r.involves(address, events)
+				if err != nil {
+					errs[position] = fmt.Errorf("could not check events (height: %d): %w", height, err)
+				}
+			}
+		}()
+	}
+
+	for position := 0; position < count; position++ {
+		positions <- position
+	}
+	close(positions)
+	wg.Wait()
+
+	// As for transactions, the first failure by height is returned, so that the
+	// returned error does not depend on the scheduling of the workers.
+	var heights []uint64
+	for position := range changed {
+		if errs[position] != nil {
+			return nil, errs[position]
+		}
+		if changed[position] {
+			heights = append(heights, start+uint64(position))
+		}
+	}
+
+	return heights, nil
+}
+
+// blockResult is what is cached for a block, which is the block itself and the
+// identifiers of the transactions that were left out of it.
+type blockResult struct {
+	Block             *object.Block            `json:"block"`
+	OtherTransactions []identifier.Transaction `json:"other_transactions,omitempty"`
 }
 
 // Block retrieves a block and its transactions given its identifier.
@@ -188,14 +497,35 @@ func (r *Retriever) Block(rosBlockID identifier.Block) (*object.Block, []identif
 		return nil, nil, fmt.Errorf("could not validate block: %w", err)
 	}
 
-	// Retrieve the Flow token default withdrawal and deposit events.
-	deposit, err := r.generate.TokensDeposited(dps.FlowSymbol)
+	// A sealed block never changes, so we only build each of them once.
+	key := fmt.Sprintf("block/%d", height)
+	value, err := r.cache.Get(key, func() (interface{}, error) {
+		block, extraTransactions, err := r.block(height, blockID)
+		if err != nil {
+			return nil, err
+		}
+		result := blockResult{
+			Block:             block,
+			OtherTransactions: extraTransactions,
+		}
+		return result, nil
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not generate deposit event type: %w", err)
+		return nil, nil, err
 	}
-	withdrawal, err := r.generate.TokensWithdrawn(dps.FlowSymbol)
+	result := value.(blockResult)
+
+	return result.Block, result.OtherTransactions, nil
+}
+
+// block builds the block with the given height and ID, with its transactions up to the
+// transaction limit, and the identifiers of the transactions beyond that limit.
+func (r *Retriever) block(height uint64, blockID flow.Identifier) (*object.Block, []identifier.Transaction, error) {
+
+	// Retrieve the withdrawal and deposit event types for all supported tokens.
+	types, err := r.eventTypes()
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not generate withdrawal event type: %w", err)
+		return nil, nil, fmt.Errorf("could not generate event types: %w", err)
 	}
 
 	// Then, get the header; it contains the block ID, parent ID and timestamp.
@@ -205,7 +535,7 @@ func (r *Retriever) Block(rosBlockID identifier.Block) (*object.Block, []identif
 	}
 
 	// Next, we get all the events for the block to extract deposit and withdrawal events.
-	events, err := r.index.Events(height, flow.EventType(deposit), flow.EventType(withdrawal))
+	events, err := r.index.Events(height, types...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get events: %w", err)
 	}
@@ -216,40 +546,56 @@ func (r *Retriever) Block(rosBlockID identifier.Block) (*object.Block, []identif
 		return nil, nil, fmt.Errorf("could not get transactions by height: %w", err)
 	}
 
-	// Go over all the transaction IDs and create the related Rosetta transaction
-	// until we hit the limit, at which point we just add the identifier.
-	var blockTransactions []*object.Transaction
+	// Map the transactions of this height to their collections.
+	collIDs, collections, err := r.collections(height)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get collections: %w", err)
+	}
+
+	// Create the related Rosetta transaction for all of the transaction IDs until
+	// we hit the limit; for the transactions after it, we just add the identifier.
+	limit := len(txIDs)
+	if limit > int(r.cfg.TransactionLimit) {
+		limit = int(r.cfg.TransactionLimit)
+	}
 	var extraTransactions []identifier.Transaction
-	for index, txID := range txIDs {
-		if index >= int(r.cfg.TransactionLimit) {
-			extraTransactions = append(extraTransactions, rosettaTxID(txID))
-			continue
-		}
-		ops, err := r.operations(txID, events)
+	for _, txID := range txIDs[limit:] {
+		extraTransactions = append(extraTransactions, rosettaTxID(txID))
+	}
+	var blockTransactions []*object.Transaction
+	if limit > 0 {
+		blockTransactions, err = r.transactions(height, txIDs[:limit], collections, types, byTransaction(events))
 		if err != nil {
-			return nil, nil, fmt.Errorf("could not get operations: %w", err)
+			return nil, nil, fmt.Errorf("could not get transactions: %w", err)
 		}
-		rosTx := object.Transaction{
-			ID:         rosettaTxID(txID),
-			Operations: ops,
-		}
-		blockTransactions = append(blockTransactions, &rosTx)
 	}
 
 	// Rosetta spec notes that for genesis block, it is recommended to use the
 	// genesis block identifier also for the parent block identifier.
 	// See https://www.rosetta-api.org/docs/common_mistakes.html#malformed-genesis-block
 	// We thus initialize the parent as the current block, and if the header is
-	// not the root block, we use its actual parent.
+	// not the root block, we use its actual parent. If the root block is not the
+	// genesis block, but the first block of a later spork, the last block of the
+	// previous spork can be configured as its parent, to keep the chain linked
+	// across sporks.
 	first, err := r.index.First()
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not get first block index: %w", err)
 	}
 	var parent identifier.Block
-	if header.Height == first {
-		parent = rosettaBlockID(height, blockID)
-	} else {
+	switch {
+	case header.Height != first:
 		parent = rosettaBlockID(height-1, header.ParentID)
+	case r.cfg.Previous != nil:
+		parent = *r.cfg.Previous
+	default:
+		parent = rosettaBlockID(height, blockID)
+	}
+
+	// Finally, we retrieve the data needed for the block metadata.
+	metadata, err := r.blockMetadata(height, header, collIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get block metadata: %w", err)
 	}
 
 	// Now we just need to build the block.
@@ -258,11 +604,89 @@ func (r *Retriever) Block(rosBlockID identifier.Block) (*object.Block, []identif
 		ParentID:     parent,
 		Timestamp:    header.Timestamp.UnixNano() / 1_000_000,
 		Transactions: blockTransactions,
+		Metadata:     metadata,
 	}
 
 	return &block, extraTransactions, nil
 }
 
+// BlockTransactions returns a page of fully populated transactions of the given block,
+// starting at the given offset within the block. The page contains at most `limit`
+// transactions, and never more than the configured transaction limit; a limit of zero
+// uses the configured transaction limit. Besides the block identifier and the page of
+// transactions, it returns the offset of the next page, which is zero when there are
+// no transactions left in the block. Only the collections and events of the
+// transactions of the page are used to build them.
+func (r *Retriever) BlockTransactions(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error) {
+
+	// Run validation on the Rosetta block identifier. If it is valid, this will
+	// return the associated Flow block height and block ID.
+	height, blockID, err := r.validate.Block(rosBlockID)
+	if err != nil {
+		return identifier.Block{}, nil, 0, fmt.Errorf("could not validate block: %w", err)
+	}
+
+	// Get all transaction IDs for this height, so we can select the ones of
+	// the requested page.
+	txIDs, err := r.index.TransactionsByHeight(height)
+	if err != nil {
+		return identifier.Block{}, nil, 0, fmt.Errorf("could not get transactions by height: %w", err)
+	}
+
+	if limit == 0 || limit > r.cfg.TransactionLimit {
+		limit = r.cfg.TransactionLimit
+	}
+	// Without a transaction limit, pages would be empty and the next offset would
+	// never move forward, so we refuse to paginate rather than loop forever.
+	if limit == 0 {
+		return identifier.Block{}, nil, 0, fmt.Errorf("transaction limit is zero")
+	}
+	total := uint(len(txIDs))
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	txIDs = txIDs[offset:end]
+
+	// The next offset is only set if there are transactions left after this page.
+	next := uint(0)
+	if end < total {
+		next = end
+	}
+
+	// If the page is empty, there is no need to look up any events.
+	if len(txIDs) == 0 {
+		return rosettaBlockID(height, blockID), []*object.Transaction{}, next, nil
+	}
+
+	types, err := r.eventTypes()
+	if err != nil {
+		return identifier.Block{}, nil, 0, fmt.Errorf("could not generate event types: %w", err)
+	}
+
+	// The index only serves the events of a whole block, so we keep the ones of
+	// the transactions of the page.
+	events, err := r.index.Events(height, types...)
+	if err != nil {
+		return identifier.Block{}, nil, 0, fmt.Errorf("could not get events: %w", err)
+	}
+
+	collections, err := r.collectionsFor(height, txIDs)
+	if err != nil {
+		return identifier.Block{}, nil, 0, fmt.Errorf("could not get collections: %w", err)
+	}
+
+	transactions, err := r.transactions(height, txIDs, collections, types, byTransaction(events, txIDs...))
+	if err != nil {
+		return identifier.Block{}, nil, 0, fmt.Errorf("could not get transactions: %w", err)
+	}
+
+	return rosettaBlockID(height, blockID), transactions, next, nil
+}
+
 // Transaction retrieves a transaction given its identifier and the identifier of the block it is a part of.
 func (r *Retriever) Transaction(rosBlockID identifier.Block, rosTxID identifier.Transaction) (*object.Transaction, error) {
 
@@ -280,6 +704,23 @@ func (r *Retriever) Transaction(rosBlockID identifier.Block, rosTxID identifier.
 		return nil, fmt.Errorf("could not validate transaction: %w", err)
 	}
 
+	// A transaction of a sealed block never changes, so we only build each of
+	// them once.
+	key := fmt.Sprintf("transaction/%d/%x", height, txID)
+	value, err := r.cache.Get(key, func() (interface{}, error) {
+		return r.blockTransaction(height, blockID, txID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*object.Transaction), nil
+}
+
+// blockTransaction builds the transaction with the given ID, after checking that it is
+// part of the block with the given height and ID.
+func (r *Retriever) blockTransaction(height uint64, blockID flow.Identifier, txID flow.Identifier) (*object.Transaction, error) {
+
 	// We retrieve all transaction IDs for the given block height to check that
 	// our transaction is part of it.
 	txIDs, err := r.index.TransactionsByHeight(height)
@@ -293,7 +734,7 @@ func (r *Retriever) Transaction(rosBlockID identifier.Block, rosTxID identifier.
 	_, ok := lookup[txID]
 	if !ok {
 		return nil, failure.UnknownTransaction{
-			Hash: rosTxID.Hash,
+			Hash: txID.String(),
 			Description: failure.NewDescription(txMissing,
 				failure.WithUint64("block_index", height),
 				failure.WithID("block_hash", blockID),
@@ -301,34 +742,31 @@ func (r *Retriever) Transaction(rosBlockID identifier.Block, rosTxID identifier.
 		}
 	}
 
-	// Retrieve the Flow token default withdrawal and deposit events.
-	deposit, err := r.generate.TokensDeposited(dps.FlowSymbol)
+	// Retrieve the withdrawal and deposit event types for all supported tokens.
+	types, err := r.eventTypes()
 	if err != nil {
-		return nil, fmt.Errorf("could not generate deposit event type: %w", err)
-	}
-	withdrawal, err := r.generate.TokensWithdrawn(dps.FlowSymbol)
-	if err != nil {
-		return nil, fmt.Errorf("could not generate withdrawal event type: %w", err)
+		return nil, fmt.Errorf("could not generate event types: %w", err)
 	}
 
 	// Retrieve the deposit and withdrawal events for the block (yes, all of them).
-	events, err := r.index.Events(height, flow.EventType(deposit), flow.EventType(withdrawal))
+	events, err := r.index.Events(height, types...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get events: %w", err)
 	}
 
-	// Convert events to operations.
-	ops, err := r.operations(txID, events)
+	// Map the transaction to its collection.
+	collections, err := r.collectionsFor(height, []flow.Identifier{txID})
 	if err != nil {
-		return nil, fmt.Errorf("could not convert events to operations: %w", err)
+		return nil, fmt.Errorf("could not get collections: %w", err)
 	}
 
-	transaction := object.Transaction{
-		ID:         rosettaTxID(txID),
-		Operations: ops,
+	// Convert the events of the transaction to operations and build the transaction.
+	transaction, err := r.transaction(height, txID, collections[txID], types, byTransaction(events, txID)[txID])
+	if err != nil {
+		return nil, fmt.Errorf("could not convert events to transaction: %w", err)
 	}
 
-	return &transaction, nil
+	return transaction, nil
 }
 
 // Sequence retrieves the sequence number of an account's public key.
@@ -358,42 +796,522 @@ func (r *Retriever) Sequence(rosBlockID identifier.Block, rosAccountID identifie
 	return key.SeqNumber, nil
 }
 
-// operations allows us to extract the operations for a transaction ID by using the given list of
-// events. In general, we retrieve all events for the block in question, so those should be passed in order to avoid
-// querying events for each transaction in a block.
-func (r *Retriever) operations(txID flow.Identifier, events []flow.Event) ([]*object.Operation, error) {
+// eventTypes returns the deposit, withdrawal, mint and burn event types of all supported tokens, followed by the fee,
+// account creation and staking event types. The order of the returned types has to be kept the same so that we can
+// keep deterministic operation indices, which is a requirement of the Rosetta API specification. Tokens are thus
+// iterated by sorted symbol, with the same order of event types for each of them.
+func (r *Retriever) eventTypes() ([]flow.EventType, error) {
+
+	symbols := r.params.Symbols()
+	types := make([]flow.EventType, 0, 4*len(symbols)+8)
+	for _, symbol := range symbols {
+		deposit, err := r.generate.TokensDeposited(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate deposit event type (symbol: %s): %w", symbol, err)
+		}
+		withdrawal, err := r.generate.TokensWithdrawn(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate withdrawal event type (symbol: %s): %w", symbol, err)
+		}
+		minted, err := r.generate.TokensMinted(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate mint event type (symbol: %s): %w", symbol, err)
+		}
+		burned, err := r.generate.TokensBurned(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate burn event type (symbol: %s): %w", symbol, err)
+		}
+		types = append(types, flow.EventType(deposit), flow.EventType(withdrawal), flow.EventType(minted), flow.EventType(burned))
+	}
 
-	// These are the currently supported event types. The order here has to be kept the same so that we can keep
-	// deterministic operation indices, which is a requirement of the Rosetta API specification.
-	deposit, err := r.generate.TokensDeposited(dps.FlowSymbol)
+	fees, err := r.generate.FeesDeducted()
 	if err != nil {
-		return nil, fmt.Errorf("could not generate deposit event type: %w", err)
+		return nil, fmt.Errorf("could not generate fees event type: %w", err)
 	}
-	withdrawal, err := r.generate.TokensWithdrawn(dps.FlowSymbol)
+	types = append(types, flow.EventType(fees))
+
+	created, err := r.generate.AccountCreated()
 	if err != nil {
-		return nil, fmt.Errorf("could not generate withdrawal event type: %w", err)
+		return nil, fmt.Errorf("could not generate account creation event type: %w", err)
 	}
-	priorities := map[string]uint{
-		deposit:    1,
-		withdrawal: 2,
+	types = append(types, flow.EventType(created))
+
+	staking := []func() (string, error){
+		r.generate.TokensCommitted,
+		r.generate.DelegatorTokensCommitted,
+		r.generate.TokensUnstaked,
+		r.generate.DelegatorTokensUnstaked,
+		r.generate.RewardsPaid,
+		r.generate.DelegatorRewardsPaid,
+	}
+	for _, generate := range staking {
+		typ, err := generate()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate staking event type: %w", err)
+		}
+		types = append(types, flow.EventType(typ))
 	}
 
-	// We then start by filtering out all events that don't have the right transaction
-	// ID or which are not a supported type. Afterwards, we sort them by priority,
-	// which  will make sure that we keep a deterministic index order for operations.
-	filtered := make([]flow.Event, 0, len(events))
+	return types, nil
+}
+
+// transaction builds the Rosetta transaction for the given transaction ID at the given height, using the given list
+// of its events and supported event types. Its metadata is built from the transaction body and result, and the given collection ID.
+// If the transaction failed during execution, its operations are marked as failed, except for the payment of the
+// transaction fee, which is charged regardless.
+func (r *Retriever) transaction(height uint64, txID flow.Identifier, collID flow.Identifier, types []flow.EventType, events []flow.Event) (*object.Transaction, error) {
+
+	ops, err := r.operations(types, events)
+	if err != nil {
+		return nil, fmt.Errorf("could not get operations: %w", err)
+	}
+
+	index := r.at(height)
+
+	tx, err := index.Transaction(txID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction: %w", err)
+	}
+
+	result, err := index.Result(txID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction result: %w", err)
+	}
+
+	// Account creation operations should name the payer that created the account,
+	// which is only available in the transaction body.
+	creations(tx.Payer, ops)
+
+	if result.ErrorMessage != "" {
+		failed(tx.Payer, r.params.FlowFees, ops)
+	}
+
+	transaction := object.Transaction{
+		ID:         rosettaTxID(txID),
+		Operations: ops,
+		Metadata:   rosettaTxMetadata(tx, result, collID),
+	}
+
+	return &transaction, nil
+}
+
+// transactions builds the Rosetta transactions for the given transaction IDs, using the given events grouped
+// by transaction ID. As each transaction is built independently, they are built concurrently by a bounded
+// number of workers, while the returned transactions keep the order of the given transaction IDs.
+func (r *Retriever) transactions(height uint64, txIDs []flow.Identifier, collections map[flow.Identifier]flow.Identifier, types []flow.EventType, events map[flow.Identifier][]flow.Event) ([]*object.Transaction, error) {
+
+	workers := int(r.cfg.Workers)
+	if workers > len(txIDs) {
+		workers = len(txIDs)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Each worker builds the transactions for the positions it receives, and puts
+	// them, or the failure to build them, at the same position of the results.
+	transactions := make([]*object.Transaction, len(txIDs))
+	errs := make([]error, len(txIDs))
+	positions := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for position := range positions {
+				txID := txIDs[position]
+				transactions[position], errs[position] = r.transaction(height, txID, collections[txID], types, events[txID])
+			}
+		}()
+	}
+
+	for position := range txIDs {
+		positions <- position
+	}
+	close(positions)
+	wg.Wait()
+
+	// We return the failure of the first transaction that could not be built, so
+	// that the returned error does not depend on the scheduling of the workers.
+	for position, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("could not get transaction (%x): %w", txIDs[position], err)
+		}
+	}
+
+	return transactions, nil
+}
+
+// at returns the index to use for lookups by identifier of the data at the given height. If the index routes
+// requests over multiple sporks, it is the index of the spork that covers the height, so that the lookups do
+// not have to go through all of the sporks.
+func (r *Retriever) at(height uint64) dps.Reader {
+	router, ok := r.index.(Router)
+	if !ok {
+		return r.index
+	}
+	index, err := router.Spork(height)
+	if err != nil {
+		return r.index
+	}
+	return index
+}
+
+// script generates the balance script for the given sub-account and currency. Without
+// a sub-account, it is the script for the balance of the main vault of the currency.
+// Sub-accounts only hold the native Flow token.
+func (r *Retriever) script(sub *identifier.SubAccount, symbol string) ([]byte, error) {
+
+	if sub == nil {
+		return r.generate.GetBalance(symbol)
+	}
+
+	if symbol != dps.FlowSymbol {
+		return nil, failure.InvalidCurrency{
+			Symbol: symbol,
+			Description: failure.NewDescription(subAccountCurrency,
+				failure.WithString("sub_account", sub.Address),
+			),
+		}
+	}
+
+	switch sub.Address {
+	case configuration.SubAccountLocked:
+		return r.generate.GetLockedBalance()
+	case configuration.SubAccountStaked:
+		return r.generate.GetStakedBalance()
+	case configuration.SubAccountDelegated:
+		return r.generate.GetDelegatedBalance()
+	case configuration.SubAccountStorageReserved:
+		return r.generate.GetStorageReserved()
+	default:
+		return nil, fmt.Errorf("unknown sub-account (%s)", sub.Address)
+	}
+}
+
+// balance executes the given balance script for the given account at the given height.
+func (r *Retriever) balance(height uint64, address flow.Address, script []byte) (uint64, error) {
+
+	params := []cadence.Value{cadence.NewAddress(address)}
+	result, err := r.invoke.Script(height, script, params)
+	if err != nil && !strings.Contains(err.Error(), missingVault) {
+		return 0, fmt.Errorf("could not invoke script: %w", err)
+	}
+
+	// In the previous error check, we exclude errors that are about getting
+	// the vault reference in Cadence. In those cases, we keep the default
+	// balance here, which is zero.
+	if err != nil {
+		return 0, nil
+	}
+	balance, ok := result.ToGoValue().(uint64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected script result type (got: %s, want uint64)", result.String())
+	}
+
+	return balance, nil
+}
+
+// tokens retrieves the balance of the vault of the given token for the given account
+// at the given height. It decodes the balance directly from the account's storage
+// registers when possible, which is much faster than executing a script, and falls
+// back to executing the given balance script for non-standard vault setups.
+func (r *Retriever) tokens(height uint64, address flow.Address, symbol string, script []byte) (uint64, error) {
+
+	balance, ok, err := r.vault.Balance(height, address, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("could not read vault balance: %w", err)
+	}
+	if ok {
+		return balance, nil
+	}
+
+	return r.balance(height, address, script)
+}
+
+// vaults retrieves the balances of the vaults of the given tokens for the given account at the given height.
+// The balances of vaults that are set up the standard way are decoded directly from the account's storage
+// registers. The others are retrieved with a single script execution for all of their tokens and, should
+// that fail, by executing the balance script of each of their tokens concurrently.
+func (r *Retriever) vaults(height uint64, address flow.Address, symbols []string) (map[string]uint64, error) {
+
+	balances := make(map[string]uint64, len(symbols))
+	var missing []string
+	for _, symbol := range symbols {
+		balance, ok, err := r.vault.Balance(height, address, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not read vault balance: %w", err)
+		}
+		if !ok {
+			missing = append(missing, symbol)
+			continue
+		}
+		balances[symbol] = balance
+	}
+
+	if len(missing) == 0 {
+		return balances, nil
+	}
+
+	scripted, err := r.batch(height, address, missing)
+	if err != nil {
+		scripted, err = r.concurrent(height, address, missing)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for symbol, balance := range scripted {
+		balances[symbol] = balance
+	}
+
+	return balances, nil
+}
+
+// batch executes a single script that reads the balances of the vaults of all of the given tokens for the
+// given account at the given height.
+func (r *Retriever) batch(height uint64, address flow.Address, symbols []string) (map[string]uint64, error) {
+
+	script, err := r.generate.GetBalances(symbols)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate script: %w", err)
+	}
+
+	params := []cadence.Value{cadence.NewAddress(address)}
+	result, err := r.invoke.Script(height, script, params)
+	if err != nil {
+		return nil, fmt.Errorf("could not invoke script: %w", err)
+	}
+
+	dictionary, ok := result.(cadence.Dictionary)
+	if !ok {
+		return nil, fmt.Errorf("unexpected script result type (got: %s, want dictionary)", result.String())
+	}
+	balances := make(map[string]uint64, len(dictionary.Pairs))
+	for _, pair := range dictionary.Pairs {
+		symbol, ok := pair.Key.(cadence.String)
+		if !ok {
+			return nil, fmt.Errorf("unexpected script result key type (got: %s, want string)", pair.Key.String())
+		}
+		balance, ok := pair.Value.ToGoValue().(uint64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected script result value type (got: %s, want uint64)", pair.Value.String())
+		}
+		balances[string(symbol)] = balance
+	}
+	for _, symbol := range symbols {
+		_, ok := balances[symbol]
+		if !ok {
+			return nil, fmt.Errorf("missing balance in script result (symbol: %s)", symbol)
+		}
+	}
+
+	return balances, nil
+}
+
+// concurrent executes the balance script of each of the given tokens for the given account at the given
+// height, with all of the scripts executed concurrently.
+func (r *Retriever) concurrent(height uint64, address flow.Address, symbols []string) (map[string]uint64, error) {
+
+	values := make([]uint64, len(symbols))
+	errs := make([]error, len(symbols))
+	var wg sync.WaitGroup
+	wg.Add(len(symbols))
+	for i, symbol := range symbols {
+		go func(i int, symbol string) {
+			defer wg.Done()
+			script, err := r.generate.GetBalance(symbol)
+			if err != nil {
+				errs[i] = fmt.Errorf("could not generate script: %w", err)
+				return
+			}
+			values[i], errs[i] = r.balance(height, address, script)
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	balances := make(map[string]uint64, len(symbols))
+	for i, symbol := range symbols {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("could not get balance (symbol: %s): %w", symbol, errs[i])
+		}
+		balances[symbol] = values[i]
+	}
+
+	return balances, nil
+}
+
+// subAccounts retrieves the balances of the given sub-account for the given tokens of the given account
+// at the given height, by executing the script for that sub-account.
+func (r *Retriever) subAccounts(height uint64, address flow.Address, sub *identifier.SubAccount, symbols []string) (map[string]uint64, error) {
+
+	balances := make(map[string]uint64, len(symbols))
+	for _, symbol := range symbols {
+		script, err := r.script(sub, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate script: %w", err)
+		}
+		balance, err := r.balance(height, address, script)
+		if err != nil {
+			return nil, fmt.Errorf("could not get balance: %w", err)
+		}
+		balances[symbol] = balance
+	}
+
+	return balances, nil
+}
+
+// involves checks whether any of the given events converts to an operation on the given account.
+func (r *Retriever) involves(address flow.Address, events []flow.Event) (bool, error) {
 	for _, event := range events {
-		if event.TransactionID != txID {
+		op, err := r.convert.EventToOperation(event)
+		if errors.Is(err, ErrNoAddress) || errors.Is(err, ErrNotSupported) {
 			continue
 		}
-		_, ok := priorities[string(event.Type)]
+		if err != nil {
+			return false, fmt.Errorf("could not convert event: %w", err)
+		}
+		if op.AccountID.Address == address.String() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// collections returns the IDs of the collections at the given height, as well as a mapping of the IDs of the
+// transactions at the given height to the IDs of the collections they are part of.
+func (r *Retriever) collections(height uint64) ([]flow.Identifier, map[flow.Identifier]flow.Identifier, error) {
+
+	collIDs, err := r.index.CollectionsByHeight(height)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get collections by height: %w", err)
+	}
+
+	index := r.at(height)
+
+	lookup := make(map[flow.Identifier]flow.Identifier)
+	for _, collID := range collIDs {
+		collection, err := index.Collection(collID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not get collection (id: %x): %w", collID, err)
+		}
+		for _, txID := range collection.Transactions {
+			lookup[txID] = collID
+		}
+	}
+
+	return collIDs, lookup, nil
+}
+
+// collectionsFor returns a mapping of the given transactions at the given height to the IDs of the collections they
+// are part of. Collections are looked up in order, until all of the given transactions are mapped.
+func (r *Retriever) collectionsFor(height uint64, txIDs []flow.Identifier) (map[flow.Identifier]flow.Identifier, error) {
+
+	collIDs, err := r.index.CollectionsByHeight(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get collections by height: %w", err)
+	}
+
+	wanted := make(map[flow.Identifier]struct{}, len(txIDs))
+	for _, txID := range txIDs {
+		wanted[txID] = struct{}{}
+	}
+
+	index := r.at(height)
+
+	lookup := make(map[flow.Identifier]flow.Identifier, len(txIDs))
+	for _, collID := range collIDs {
+		if len(lookup) == len(wanted) {
+			break
+		}
+		collection, err := index.Collection(collID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get collection (id: %x): %w", collID, err)
+		}
+		for _, txID := range collection.Transactions {
+			_, ok := wanted[txID]
+			if !ok {
+				continue
+			}
+			lookup[txID] = collID
+		}
+	}
+
+	return lookup, nil
+}
+
+// blockMetadata builds the metadata for the block at the given height, using its header and the IDs of the
+// collections it contains.
+func (r *Retriever) blockMetadata(height uint64, header *flow.Header, collIDs []flow.Identifier) (*object.BlockMetadata, error) {
+
+	commit, err := r.index.Commit(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get state commitment: %w", err)
+	}
+
+	index := r.at(height)
+
+	guarantees := make([]object.Guarantee, 0, len(collIDs))
+	for _, collID := range collIDs {
+		guarantee, err := index.Guarantee(collID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get collection guarantee (id: %x): %w", collID, err)
+		}
+		guarantees = append(guarantees, rosettaGuarantee(guarantee))
+	}
+
+	sealIDs, err := r.index.SealsByHeight(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get seals by height: %w", err)
+	}
+	seals := make([]object.Seal, 0, len(sealIDs))
+	for _, sealID := range sealIDs {
+		seal, err := index.Seal(sealID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get seal (id: %x): %w", sealID, err)
+		}
+		seals = append(seals, rosettaSeal(seal))
+	}
+
+	metadata := object.BlockMetadata{
+		ProposerID:      header.ProposerID.String(),
+		View:            header.View,
+		StateCommitment: hex.EncodeToString(commit[:]),
+		Guarantees:      guarantees,
+		Seals:           seals,
+	}
+
+	return &metadata, nil
+}
+
+// operations allows us to extract the operations of a transaction by using the given list of its events.
+// In general, we retrieve all events for the block in question and group them by transaction, so that we
+// avoid querying events for each transaction in a block. The given event types are the supported ones, in
+// order of priority.
+func (r *Retriever) operations(types []flow.EventType, events []flow.Event) ([]*object.Operation, error) {
+
+	// The priority of each event type is given by its position in the list of types.
+	priorities := make(map[flow.EventType]int, len(types))
+	for priority, typ := range types {
+		priorities[typ] = priority
+	}
+
+	// We then start by filtering out all events which are not a supported type.
+	// Afterwards, we sort them by priority, and by event index for equal priorities,
+	// which will make sure that we keep a deterministic index order for operations.
+	filtered := make([]flow.Event, 0, len(events))
+	for _, event := range events {
+		_, ok := priorities[event.Type]
 		if !ok {
 			continue
 		}
 		filtered = append(filtered, event)
 	}
 	sort.Slice(filtered, func(i int, j int) bool {
-		return priorities[string(events[i].Type)] < priorities[string(events[j].Type)]
+		left, right := filtered[i], filtered[j]
+		if priorities[left.Type] != priorities[right.Type] {
+			return priorities[left.Type] < priorities[right.Type]
+		}
+		return left.EventIndex < right.EventIndex
 	})
 
 	// Now we can convert each event to an operation, as they are both filtered for
@@ -415,10 +1333,234 @@ func (r *Retriever) operations(txID flow.Identifier, events []flow.Event) ([]*ob
 		ops = append(ops, op)
 	}
 
-	// Finally, we can assign the indices.
+	// Fee, mint and burn operations are not associated with an account, so we
+	// attribute them by pairing them with the transfers that moved the tokens.
+	ops = pair(ops)
+
+	// Finally, we can assign the indices and link related operations.
 	for index, op := range ops {
 		op.ID.Index = uint(index)
 	}
+	relate(ops)
 
 	return ops, nil
 }
+
+// byTransaction groups the given events by the ID of the transaction that emitted them, keeping their order.
+// If transaction IDs are given, only the events emitted by those transactions are kept.
+func byTransaction(events []flow.Event, txIDs ...flow.Identifier) map[flow.Identifier][]flow.Event {
+	wanted := make(map[flow.Identifier]struct{}, len(txIDs))
+	for _, txID := range txIDs {
+		wanted[txID] = struct{}{}
+	}
+	grouped := make(map[flow.Identifier][]flow.Event)
+	for _, event := range events {
+		_, ok := wanted[event.TransactionID]
+		if len(wanted) > 0 && !ok {
+			continue
+		}
+		grouped[event.TransactionID] = append(grouped[event.TransactionID], event)
+	}
+	return grouped
+}
+
+// relate links each withdrawal to the deposits that received the withdrawn tokens. A withdrawal is paired with the
+// first deposit of the same amount and currency that happened after it. If there is no such deposit, the vault might
+// have been split, and the withdrawal is paired with the deposits that followed it, in order, as long as they add up
+// to exactly the withdrawn amount. Each deposit is only paired once. As the Rosetta API specification requires
+// related operations to have a lower index, the link is added to whichever of the two operations comes last.
+func relate(ops []*object.Operation) {
+
+	// Only transfers and fees move tokens from one vault to another.
+	type movement struct {
+		op     *object.Operation
+		amount int64
+	}
+	var withdrawals, deposits []movement
+	for _, op := range ops {
+		if op.Type != dps.OperationTransfer && op.Type != configuration.OperationFee {
+			continue
+		}
+		if op.Amount == nil || op.ID.NetworkIndex == nil {
+			continue
+		}
+		amount, err := strconv.ParseInt(op.Amount.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case amount < 0:
+			withdrawals = append(withdrawals, movement{op: op, amount: -amount})
+		case amount > 0:
+			deposits = append(deposits, movement{op: op, amount: amount})
+		}
+	}
+
+	// Process both withdrawals and deposits in the order in which they happened.
+	sort.Slice(withdrawals, func(i int, j int) bool {
+		return *withdrawals[i].op.ID.NetworkIndex < *withdrawals[j].op.ID.NetworkIndex
+	})
+	sort.Slice(deposits, func(i int, j int) bool {
+		return *deposits[i].op.ID.NetworkIndex < *deposits[j].op.ID.NetworkIndex
+	})
+
+	paired := make(map[*object.Operation]struct{})
+	for _, withdrawal := range withdrawals {
+
+		// Collect the candidate deposits, which are the unpaired deposits of the
+		// same currency that happened after the withdrawal.
+		var candidates []movement
+		for _, deposit := range deposits {
+			_, ok := paired[deposit.op]
+			if ok {
+				continue
+			}
+			if deposit.op.Amount.Currency != withdrawal.op.Amount.Currency {
+				continue
+			}
+			if *deposit.op.ID.NetworkIndex < *withdrawal.op.ID.NetworkIndex {
+				continue
+			}
+			candidates = append(candidates, deposit)
+		}
+
+		// Look for a deposit of the exact amount first, and otherwise for a sequence
+		// of deposits that add up to the amount.
+		var matched []movement
+		for _, candidate := range candidates {
+			if candidate.amount == withdrawal.amount {
+				matched = []movement{candidate}
+				break
+			}
+		}
+		if len(matched) == 0 {
+			sum := int64(0)
+			for _, candidate := range candidates {
+				if sum+candidate.amount > withdrawal.amount {
+					break
+				}
+				sum += candidate.amount
+				matched = append(matched, candidate)
+			}
+			if sum != withdrawal.amount {
+				continue
+			}
+		}
+
+		for _, deposit := range matched {
+			paired[deposit.op] = struct{}{}
+			first, last := withdrawal.op, deposit.op
+			if first.ID.Index > last.ID.Index {
+				first, last = last, first
+			}
+			last.RelatedIDs = append(last.RelatedIDs, first.ID)
+		}
+	}
+}
+
+// pair pairs each operation that is not associated with an account with the transfer operation that moved its
+// tokens, and turns that transfer into an operation of the same type. Debiting operations, such as fees and burns,
+// are paired with the last withdrawal of the same amount and currency that happened before them, which is the
+// withdrawal from the vault of the payer or the burner. Crediting operations, such as mints, are paired with the
+// first deposit of the same amount and currency that happened after them, which is the deposit into the vault of
+// the recipient. Operations that can not be paired are dropped, as they can not be attributed to an account.
+func pair(ops []*object.Operation) []*object.Operation {
+
+	paired := make([]*object.Operation, 0, len(ops))
+	var unpaired []*object.Operation
+	for _, op := range ops {
+		if op.AccountID.Address == "" && op.Amount != nil {
+			unpaired = append(unpaired, op)
+			continue
+		}
+		paired = append(paired, op)
+	}
+
+	for _, marker := range unpaired {
+		debit := strings.HasPrefix(marker.Amount.Value, "-")
+
+		var transfer *object.Operation
+		for _, op := range paired {
+			if op.Type != dps.OperationTransfer || op.Amount == nil || *op.Amount != *marker.Amount {
+				continue
+			}
+
+			// Withdrawals have to precede the operation, and the latest one wins,
+			// while deposits have to follow the operation, and the earliest one wins.
+			index, markerIndex := *op.ID.NetworkIndex, *marker.ID.NetworkIndex
+			if debit && (index > markerIndex || (transfer != nil && *transfer.ID.NetworkIndex > index)) {
+				continue
+			}
+			if !debit && (index < markerIndex || (transfer != nil && *transfer.ID.NetworkIndex < index)) {
+				continue
+			}
+			transfer = op
+		}
+		if transfer == nil {
+			continue
+		}
+
+		transfer.Type = marker.Type
+	}
+
+	return paired
+}
+
+// failed marks the given operations of a failed transaction as failed, except for the ones that pay the transaction
+// fee, which is charged regardless. Those are the fee operations, the deposits into the FlowFees account, and the
+// withdrawals from the vault of the payer that are related to such deposits, which covers the fees that could not be
+// paired with the withdrawal of the payer. Withdrawals from other accounts are marked as failed even if they were
+// related to a deposit into the FlowFees account, as only the payer is charged for the fee.
+func failed(payer flow.Address, fees flow.Address, ops []*object.Operation) {
+
+	charged := make(map[uint]struct{})
+	for _, op := range ops {
+		if op.Type == configuration.OperationFee || op.AccountID.Address == fees.String() {
+			charged[op.ID.Index] = struct{}{}
+		}
+	}
+
+	// Related operations are linked from whichever of the two comes last, so we
+	// need to look at the links in both directions.
+	for _, op := range ops {
+		if op.AccountID.Address != fees.String() {
+			continue
+		}
+		for _, related := range op.RelatedIDs {
+			if related.Index < uint(len(ops)) && ops[related.Index].AccountID.Address == payer.String() {
+				charged[related.Index] = struct{}{}
+			}
+		}
+	}
+	for _, op := range ops {
+		if op.AccountID.Address != payer.String() {
+			continue
+		}
+		for _, related := range op.RelatedIDs {
+			if related.Index < uint(len(ops)) && ops[related.Index].AccountID.Address == fees.String() {
+				charged[op.ID.Index] = struct{}{}
+			}
+		}
+	}
+
+	for _, op := range ops {
+		_, ok := charged[op.ID.Index]
+		if ok {
+			continue
+		}
+		op.Status = configuration.StatusFailed.Status
+	}
+}
+
+// creations adds the given payer to the metadata of the given account creation operations.
+func creations(payer flow.Address, ops []*object.Operation) {
+	for _, op := range ops {
+		if op.Type != configuration.OperationCreateAccount {
+			continue
+		}
+		if op.Metadata == nil {
+			op.Metadata = &object.OperationMetadata{}
+		}
+		op.Metadata.Payer = payer.String()
+	}
+}
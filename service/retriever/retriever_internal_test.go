@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/testing/mocks"
 	"github.com/optakt/flow-dps/models/dps"
 )
@@ -30,9 +31,11 @@ func TestNew(t *testing.T) {
 	validate := mocks.BaselineValidator(t)
 	generator := mocks.BaselineGenerator(t)
 	invoke := mocks.BaselineInvoker(t)
+	vault := mocks.BaselineVault(t)
 	convert := mocks.BaselineConverter(t)
+	cache := mocks.BaselineResponseCache(t)
 
-	r := New(params, index, validate, generator, invoke, convert)
+	r := New(params, index, validate, generator, invoke, vault, convert, cache)
 
 	require.NotNil(t, r)
 	assert.Equal(t, params, r.params)
@@ -40,20 +43,24 @@ func TestNew(t *testing.T) {
 	assert.Equal(t, validate, r.validate)
 	assert.Equal(t, generator, r.generate)
 	assert.Equal(t, invoke, r.invoke)
+	assert.Equal(t, vault, r.vault)
 	assert.Equal(t, convert, r.convert)
+	assert.Equal(t, cache, r.cache)
 }
 
 func BaselineRetriever(t *testing.T, opts ...func(*Retriever)) *Retriever {
 	t.Helper()
 
 	r := Retriever{
-		cfg:      Config{TransactionLimit: 999},
+		cfg:      Config{TransactionLimit: 999, HistoryLimit: 999, Workers: 4},
 		params:   mocks.GenericParams,
 		index:    mocks.BaselineReader(t),
 		validate: mocks.BaselineValidator(t),
 		generate: mocks.BaselineGenerator(t),
 		invoke:   mocks.BaselineInvoker(t),
+		vault:    mocks.BaselineVault(t),
 		convert:  mocks.BaselineConverter(t),
+		cache:    mocks.BaselineResponseCache(t),
 	}
 
 	for _, opt := range opts {
@@ -87,6 +94,18 @@ func WithInvoker(invoke Invoker) func(*Retriever) {
 	}
 }
 
+func WithVault(vault Vault) func(*Retriever) {
+	return func(retriever *Retriever) {
+		retriever.vault = vault
+	}
+}
+
+func WithCache(cache Cache) func(*Retriever) {
+	return func(retriever *Retriever) {
+		retriever.cache = cache
+	}
+}
+
 func WithConverter(convert Converter) func(*Retriever) {
 	return func(retriever *Retriever) {
 		retriever.convert = convert
@@ -104,3 +123,21 @@ func WithLimit(limit uint) func(*Retriever) {
 		retriever.cfg.TransactionLimit = limit
 	}
 }
+
+func WithHistory(limit uint64) func(*Retriever) {
+	return func(retriever *Retriever) {
+		retriever.cfg.HistoryLimit = limit
+	}
+}
+
+func WithPreviousID(previous identifier.Block) func(*Retriever) {
+	return func(retriever *Retriever) {
+		retriever.cfg.Previous = &previous
+	}
+}
+
+func WithGenesisID(genesis identifier.Block) func(*Retriever) {
+	return func(retriever *Retriever) {
+		retriever.cfg.Genesis = &genesis
+	}
+}
@@ -15,7 +15,10 @@
 package retriever_test
 
 import (
+	"encoding/hex"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,6 +26,8 @@ import (
 	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/failure"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 	"github.com/optakt/flow-dps-rosetta/service/object"
 	"github.com/optakt/flow-dps-rosetta/service/retriever"
@@ -86,6 +91,60 @@ func TestRetriever_Oldest(t *testing.T) {
 	})
 }
 
+func TestRetriever_Genesis(t *testing.T) {
+	header := mocks.GenericHeader
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		ret := retriever.BaselineRetriever(t)
+
+		genesis, err := ret.Genesis()
+
+		require.NoError(t, err)
+		assert.Equal(t, header.ID().String(), genesis.Hash)
+		require.NotNil(t, genesis.Index)
+		assert.Equal(t, header.Height, *genesis.Index)
+	})
+
+	t.Run("nominal case with configured genesis", func(t *testing.T) {
+		t.Parallel()
+
+		height := uint64(7601063)
+		want := identifier.Block{
+			Index: &height,
+			Hash:  mocks.GenericHeader.ParentID.String(),
+		}
+
+		index := mocks.BaselineReader(t)
+		index.FirstFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithGenesisID(want))
+
+		genesis, err := ret.Genesis()
+
+		require.NoError(t, err)
+		assert.Equal(t, want, genesis)
+	})
+
+	t.Run("handles index.First failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.FirstFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, err := ret.Genesis()
+
+		assert.Error(t, err)
+	})
+}
+
 func TestRetriever_Current(t *testing.T) {
 	header := mocks.GenericHeader
 
@@ -139,299 +198,2395 @@ func TestRetriever_Current(t *testing.T) {
 	})
 }
 
-func TestRetriever_Balances(t *testing.T) {
+func TestRetriever_Peers(t *testing.T) {
 	header := mocks.GenericHeader
-	account := mocks.GenericAccount
-	address := cadence.NewAddress(account.Address)
-	currency := mocks.GenericCurrency
-	rosBlockID := mocks.GenericRosBlockID
-	accountID := mocks.GenericAccountID(0)
-	op := mocks.GenericOperation(0)
+
+	nodeType := &cadence.StructType{
+		QualifiedIdentifier: "FlowIDTableStaking.NodeInfo",
+		Fields: []cadence.Field{
+			{Identifier: "id", Type: cadence.StringType{}},
+			{Identifier: "role", Type: cadence.UInt8Type{}},
+			{Identifier: "networkingAddress", Type: cadence.StringType{}},
+			{Identifier: "tokensStaked", Type: cadence.UFix64Type{}},
+		},
+	}
+	node := func(id string, role uint8, address string) cadence.Value {
+		return cadence.NewStruct([]cadence.Value{
+			cadence.String(id),
+			cadence.NewUInt8(role),
+			cadence.String(address),
+			mocks.GenericAmount(0),
+		}).WithType(nodeType)
+	}
+	nodes := cadence.NewArray([]cadence.Value{
+		node("node-1", uint8(flow.RoleCollection), "collection.flow:3569"),
+		node("node-2", uint8(flow.RoleExecution), "execution.flow:3569"),
+	})
 
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
-		validator := mocks.BaselineValidator(t)
-		validator.AccountFunc = func(address identifier.Account) (flow.Address, error) {
-			assert.Equal(t, accountID, address)
-
-			return account.Address, nil
+		index := mocks.BaselineReader(t)
+		index.LastFunc = func() (uint64, error) {
+			return header.Height, nil
 		}
-		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
-			assert.Equal(t, rosBlockID, rosBlockID)
 
-			return header.Height, header.ID(), nil
-		}
-		validator.CurrencyFunc = func(currency identifier.Currency) (string, uint, error) {
-			assert.Equal(t, mocks.GenericCurrency, currency)
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(height uint64, script []byte, parameters []cadence.Value) (cadence.Value, error) {
+			assert.Equal(t, header.Height, height)
+			assert.Empty(t, parameters)
 
-			return currency.Symbol, currency.Decimals, nil
+			return nodes, nil
 		}
 
-		generator := mocks.BaselineGenerator(t)
-		generator.GetBalanceFunc = func(symbol string) ([]byte, error) {
-			assert.Equal(t, currency.Symbol, symbol)
+		ret := retriever.BaselineRetriever(t,
+			retriever.WithIndex(index),
+			retriever.WithInvoker(invoke),
+		)
 
-			return []byte(`test`), nil
-		}
+		peers, err := ret.Peers()
 
-		invoker := mocks.BaselineInvoker(t)
-		invoker.ScriptFunc = func(height uint64, script []byte, parameters []cadence.Value) (cadence.Value, error) {
-			assert.Equal(t, rosBlockID.Index, &height)
-			assert.Equal(t, []byte(`test`), script)
-			require.Len(t, parameters, 1)
-			assert.Equal(t, address, parameters[0])
+		require.NoError(t, err)
+		require.Len(t, peers, 2)
+		assert.Equal(t, "node-1", peers[0].ID)
+		assert.Equal(t, flow.RoleCollection.String(), peers[0].Metadata.Role)
+		assert.Equal(t, "collection.flow:3569", peers[0].Metadata.NetworkingAddress)
+		assert.Equal(t, "node-2", peers[1].ID)
+		assert.Equal(t, flow.RoleExecution.String(), peers[1].Metadata.Role)
+		assert.Equal(t, "execution.flow:3569", peers[1].Metadata.NetworkingAddress)
+	})
 
-			return mocks.GenericAmount(0), nil
+	t.Run("caches peers until a new block is indexed", func(t *testing.T) {
+		t.Parallel()
+
+		last := header.Height
+		index := mocks.BaselineReader(t)
+		index.LastFunc = func() (uint64, error) {
+			return last, nil
 		}
 
-		ret := retriever.BaselineRetriever(
-			t,
-			retriever.WithGenerator(generator),
-			retriever.WithInvoker(invoker),
-			retriever.WithValidator(validator),
-			retriever.WithLimit(5),
-		)
+		var heights []uint64
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(height uint64, _ []byte, _ []cadence.Value) (cadence.Value, error) {
+			heights = append(heights, height)
+			return nodes, nil
+		}
 
-		blockID, amounts, err := ret.Balances(
-			rosBlockID,
-			accountID,
-			[]identifier.Currency{currency},
+		ret := retriever.BaselineRetriever(t,
+			retriever.WithIndex(index),
+			retriever.WithInvoker(invoke),
 		)
 
+		first, err := ret.Peers()
+		require.NoError(t, err)
+		second, err := ret.Peers()
 		require.NoError(t, err)
-		assert.Equal(t, rosBlockID, blockID)
 
-		wantAmounts := []object.Amount{
-			op.Amount,
-		}
-		assert.Equal(t, wantAmounts, amounts)
+		last++
+		third, err := ret.Peers()
+		require.NoError(t, err)
+
+		assert.Equal(t, []uint64{header.Height, header.Height + 1}, heights)
+		assert.Equal(t, first, second)
+		assert.Equal(t, first, third)
 	})
 
-	t.Run("handles invalid block", func(t *testing.T) {
+	t.Run("does not cache failures", func(t *testing.T) {
 		t.Parallel()
 
-		validator := mocks.BaselineValidator(t)
-		validator.BlockFunc = func(identifier.Block) (uint64, flow.Identifier, error) {
-			return 0, flow.ZeroID, mocks.GenericError
+		calls := 0
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			calls++
+			if calls == 1 {
+				return nil, mocks.GenericError
+			}
+			return nodes, nil
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoke))
 
-		_, _, err := ret.Balances(
-			rosBlockID,
-			accountID,
-			[]identifier.Currency{currency},
-		)
-		assert.Error(t, err)
+		_, err := ret.Peers()
+		require.Error(t, err)
+
+		peers, err := ret.Peers()
+		require.NoError(t, err)
+		assert.Len(t, peers, 2)
 	})
 
-	t.Run("handles invalid account", func(t *testing.T) {
+	t.Run("handles no staked nodes", func(t *testing.T) {
 		t.Parallel()
 
-		validator := mocks.BaselineValidator(t)
-		validator.AccountFunc = func(identifier.Account) (flow.Address, error) {
-			return flow.EmptyAddress, mocks.GenericError
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			return cadence.NewArray([]cadence.Value{}), nil
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoke))
 
-		_, _, err := ret.Balances(
-			rosBlockID,
-			accountID,
-			[]identifier.Currency{currency},
-		)
-		assert.Error(t, err)
+		peers, err := ret.Peers()
+
+		require.NoError(t, err)
+		assert.NotNil(t, peers)
+		assert.Empty(t, peers)
 	})
 
-	t.Run("handles invalid currency", func(t *testing.T) {
+	t.Run("handles index.Last failure", func(t *testing.T) {
 		t.Parallel()
 
-		validator := mocks.BaselineValidator(t)
-		validator.CurrencyFunc = func(identifier.Currency) (string, uint, error) {
-			return "", 0, mocks.GenericError
+		index := mocks.BaselineReader(t)
+		index.LastFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, err := ret.Peers()
 
-		_, _, err := ret.Balances(
-			rosBlockID,
-			accountID,
-			[]identifier.Currency{currency},
-		)
 		assert.Error(t, err)
 	})
 
-	t.Run("handles generate failure", func(t *testing.T) {
+	t.Run("handles generator failure", func(t *testing.T) {
 		t.Parallel()
 
 		generator := mocks.BaselineGenerator(t)
-		generator.GetBalanceFunc = func(string) ([]byte, error) {
+		generator.GetStakedNodesFunc = func() ([]byte, error) {
 			return nil, mocks.GenericError
 		}
 
 		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
 
-		_, _, err := ret.Balances(
-			rosBlockID,
-			accountID,
-			[]identifier.Currency{currency},
-		)
+		_, err := ret.Peers()
+
 		assert.Error(t, err)
 	})
 
 	t.Run("handles invoker failure", func(t *testing.T) {
 		t.Parallel()
 
-		invoker := mocks.BaselineInvoker(t)
-		invoker.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
 			return nil, mocks.GenericError
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoker))
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoke))
+
+		_, err := ret.Peers()
 
-		_, _, err := ret.Balances(
-			rosBlockID,
-			accountID,
-			[]identifier.Currency{currency},
-		)
 		assert.Error(t, err)
 	})
-}
 
-func TestRetriever_Block(t *testing.T) {
-	header := mocks.GenericHeader
-	rosBlockID := mocks.GenericRosBlockID
+	t.Run("handles invalid script result", func(t *testing.T) {
+		t.Parallel()
 
-	transactions := mocks.GenericTransactionIDs(5)
-	withdrawalType := mocks.GenericEventType(0)
-	depositType := mocks.GenericEventType(1)
-	withdrawals := mocks.GenericEvents(2, withdrawalType)
-	deposits := mocks.GenericEvents(2, depositType)
-	events := append(withdrawals, deposits...)
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			return cadence.NewUInt64(42), nil
+		}
 
-	t.Run("nominal case with limit not reached", func(t *testing.T) {
-		t.Parallel()
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoke))
 
-		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
-			assert.Equal(t, header.Height, height)
+		_, err := ret.Peers()
 
-			return header, nil
-		}
-		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
-			assert.Equal(t, header.Height, height)
+		assert.Error(t, err)
+	})
 
-			return transactions, nil
+	t.Run("handles invalid node role", func(t *testing.T) {
+		t.Parallel()
+
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			return cadence.NewArray([]cadence.Value{node("node-1", 42, "unknown.flow:3569")}), nil
 		}
-		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
-			assert.Equal(t, header.Height, height)
 
-			return events, nil
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoke))
+
+		_, err := ret.Peers()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing node fields", func(t *testing.T) {
+		t.Parallel()
+
+		invoke := mocks.BaselineInvoker(t)
+		invoke.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			incomplete := cadence.NewStruct([]cadence.Value{cadence.String("node-1")}).
+				WithType(&cadence.StructType{Fields: []cadence.Field{{Identifier: "id", Type: cadence.StringType{}}}})
+			return cadence.NewArray([]cadence.Value{incomplete}), nil
 		}
 
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoke))
+
+		_, err := ret.Peers()
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRetriever_Balances(t *testing.T) {
+	header := mocks.GenericHeader
+	account := mocks.GenericAccount
+	address := cadence.NewAddress(account.Address)
+	currency := mocks.GenericCurrency
+	rosBlockID := mocks.GenericRosBlockID
+	accountID := mocks.GenericAccountID(0)
+	op := mocks.GenericOperation(0)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
 		validator := mocks.BaselineValidator(t)
+		validator.AccountFunc = func(address identifier.Account) (flow.Address, error) {
+			assert.Equal(t, accountID, address)
+
+			return account.Address, nil
+		}
 		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
 			assert.Equal(t, rosBlockID, rosBlockID)
 
 			return header.Height, header.ID(), nil
 		}
+		validator.CurrencyFunc = func(currency identifier.Currency) (string, uint, error) {
+			assert.Equal(t, mocks.GenericCurrency, currency)
+
+			return currency.Symbol, currency.Decimals, nil
+		}
 
 		generator := mocks.BaselineGenerator(t)
-		generator.TokensDepositedFunc = func(symbol string) (string, error) {
-			assert.Equal(t, symbol, dps.FlowSymbol)
+		generator.GetBalancesFunc = func(symbols []string) ([]byte, error) {
+			assert.Equal(t, []string{currency.Symbol}, symbols)
 
-			return string(withdrawalType), nil
+			return []byte(`batch`), nil
 		}
-		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
-			assert.Equal(t, symbol, dps.FlowSymbol)
-
-			return string(depositType), nil
+		generator.GetBalanceFunc = func(string) ([]byte, error) {
+			t.Fatal("single balance script should not be used when the batched script succeeds")
+			return nil, nil
 		}
 
-		convert := mocks.BaselineConverter(t)
-		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
-			var op object.Operation
-			switch event.Type {
-			case withdrawalType:
-				assert.Contains(t, withdrawals, event)
-				op = mocks.GenericOperation(0)
-			case depositType:
-				assert.Contains(t, deposits, event)
-				op = mocks.GenericOperation(1)
-			}
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(height uint64, script []byte, parameters []cadence.Value) (cadence.Value, error) {
+			assert.Equal(t, rosBlockID.Index, &height)
+			assert.Equal(t, []byte(`batch`), script)
+			require.Len(t, parameters, 1)
+			assert.Equal(t, address, parameters[0])
 
-			return &op, nil
+			balances := cadence.NewDictionary([]cadence.KeyValuePair{
+				{Key: cadence.String(currency.Symbol), Value: mocks.GenericAmount(0)},
+			})
+			return balances, nil
 		}
 
 		ret := retriever.BaselineRetriever(
 			t,
 			retriever.WithGenerator(generator),
-			retriever.WithIndex(index),
+			retriever.WithInvoker(invoker),
 			retriever.WithValidator(validator),
-			retriever.WithConverter(convert),
-			retriever.WithLimit(6),
+			retriever.WithLimit(5),
 		)
 
-		block, extra, err := ret.Block(rosBlockID)
+		blockID, amounts, err := ret.Balances(
+			rosBlockID,
+			accountID,
+			[]identifier.Currency{currency},
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosBlockID, blockID)
+
+		wantAmounts := []object.Amount{
+			*op.Amount,
+		}
+		assert.Equal(t, wantAmounts, amounts)
+	})
+
+	t.Run("nominal case with multiple currencies", func(t *testing.T) {
+		t.Parallel()
+
+		other := identifier.Currency{Symbol: "TEST", Decimals: 8}
+
+		validator := mocks.BaselineValidator(t)
+		validator.CurrencyFunc = func(currency identifier.Currency) (string, uint, error) {
+			return currency.Symbol, currency.Decimals, nil
+		}
+
+		// Only the balances that cannot be read from the vault registers should
+		// be retrieved by executing a script.
+		vault := mocks.BaselineVault(t)
+		vault.BalanceFunc = func(_ uint64, _ flow.Address, symbol string) (uint64, bool, error) {
+			if symbol == currency.Symbol {
+				return 42, true, nil
+			}
+			return 0, false, nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.GetBalancesFunc = func(symbols []string) ([]byte, error) {
+			assert.Equal(t, []string{other.Symbol}, symbols)
+
+			return []byte(`batch`), nil
+		}
+
+		var calls int
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			calls++
+			balances := cadence.NewDictionary([]cadence.KeyValuePair{
+				{Key: cadence.String(other.Symbol), Value: cadence.UFix64(1337)},
+			})
+			return balances, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithInvoker(invoker),
+			retriever.WithValidator(validator),
+			retriever.WithVault(vault),
+		)
+
+		_, amounts, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency, other})
+
+		require.NoError(t, err)
+		require.Len(t, amounts, 2)
+		assert.Equal(t, currency, amounts[0].Currency)
+		assert.Equal(t, "42", amounts[0].Value)
+		assert.Equal(t, other, amounts[1].Currency)
+		assert.Equal(t, "1337", amounts[1].Value)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("nominal case with script fallback", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.GetBalancesFunc = func([]string) ([]byte, error) {
+			return []byte(`batch`), nil
+		}
+		generator.GetBalanceFunc = func(symbol string) ([]byte, error) {
+			assert.Equal(t, currency.Symbol, symbol)
+
+			return []byte(`single`), nil
+		}
+
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(_ uint64, script []byte, _ []cadence.Value) (cadence.Value, error) {
+			if string(script) == `batch` {
+				return nil, mocks.GenericError
+			}
+			assert.Equal(t, []byte(`single`), script)
+
+			return mocks.GenericAmount(0), nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator), retriever.WithInvoker(invoker))
+
+		_, amounts, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency})
+
+		require.NoError(t, err)
+		assert.Equal(t, []object.Amount{*op.Amount}, amounts)
+	})
+
+	t.Run("handles unexpected batched script result", func(t *testing.T) {
+		t.Parallel()
+
+		// A dictionary without the requested currency is not a valid result, so
+		// the balance is retrieved with the single balance script instead.
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(_ uint64, script []byte, _ []cadence.Value) (cadence.Value, error) {
+			if string(script) == `batch` {
+				return cadence.NewDictionary(nil), nil
+			}
+			return mocks.GenericAmount(0), nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.GetBalancesFunc = func([]string) ([]byte, error) {
+			return []byte(`batch`), nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator), retriever.WithInvoker(invoker))
+
+		_, amounts, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency})
+
+		require.NoError(t, err)
+		assert.Equal(t, []object.Amount{*op.Amount}, amounts)
+	})
+
+	t.Run("nominal case with sub-accounts", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		scripts := map[string]func() ([]byte, error){
+			configuration.SubAccountLocked:          generator.GetLockedBalanceFunc,
+			configuration.SubAccountStaked:          generator.GetStakedBalanceFunc,
+			configuration.SubAccountDelegated:       generator.GetDelegatedBalanceFunc,
+			configuration.SubAccountStorageReserved: generator.GetStorageReservedFunc,
+		}
+		generator.GetBalanceFunc = func(string) ([]byte, error) {
+			t.Fatal("main vault script should not be used for sub-accounts")
+			return nil, nil
+		}
+
+		for name, generate := range scripts {
+			want, err := generate()
+			require.NoError(t, err)
+
+			invoker := mocks.BaselineInvoker(t)
+			invoker.ScriptFunc = func(height uint64, script []byte, parameters []cadence.Value) (cadence.Value, error) {
+				assert.Equal(t, want, script)
+				require.Len(t, parameters, 1)
+				assert.Equal(t, address, parameters[0])
+
+				return mocks.GenericAmount(0), nil
+			}
+
+			ret := retriever.BaselineRetriever(
+				t,
+				retriever.WithGenerator(generator),
+				retriever.WithInvoker(invoker),
+			)
+
+			subAccountID := accountID
+			subAccountID.SubAccount = &identifier.SubAccount{Address: name}
+
+			_, amounts, err := ret.Balances(
+				rosBlockID,
+				subAccountID,
+				[]identifier.Currency{currency},
+			)
+
+			require.NoError(t, err)
+			assert.Equal(t, []object.Amount{*op.Amount}, amounts)
+		}
+	})
+
+	t.Run("nominal case with vault balance", func(t *testing.T) {
+		t.Parallel()
+
+		vault := mocks.BaselineVault(t)
+		vault.BalanceFunc = func(height uint64, gotAddress flow.Address, symbol string) (uint64, bool, error) {
+			assert.Equal(t, header.Height, height)
+			assert.Equal(t, account.Address, gotAddress)
+			assert.Equal(t, currency.Symbol, symbol)
+
+			return 42, true, nil
+		}
+
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			t.Fatal("balance script should not be used when the vault balance is available")
+			return nil, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithVault(vault), retriever.WithInvoker(invoker))
+
+		_, amounts, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency})
+
+		require.NoError(t, err)
+		require.Len(t, amounts, 1)
+		assert.Equal(t, currency, amounts[0].Currency)
+		assert.Equal(t, "42", amounts[0].Value)
+	})
+
+	t.Run("sub-accounts do not use vault balance", func(t *testing.T) {
+		t.Parallel()
+
+		vault := mocks.BaselineVault(t)
+		vault.BalanceFunc = func(uint64, flow.Address, string) (uint64, bool, error) {
+			t.Fatal("vault balance should not be used for sub-accounts")
+			return 0, false, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithVault(vault))
+
+		subAccountID := accountID
+		subAccountID.SubAccount = &identifier.SubAccount{Address: configuration.SubAccountLocked}
+
+		_, _, err := ret.Balances(rosBlockID, subAccountID, []identifier.Currency{currency})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("handles vault failure", func(t *testing.T) {
+		t.Parallel()
+
+		vault := mocks.BaselineVault(t)
+		vault.BalanceFunc = func(uint64, flow.Address, string) (uint64, bool, error) {
+			return 0, false, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithVault(vault))
+
+		_, _, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("uses cached balance", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		vault := mocks.BaselineVault(t)
+		vault.BalanceFunc = func(uint64, flow.Address, string) (uint64, bool, error) {
+			calls++
+			return 42, true, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithVault(vault), retriever.WithCache(memoCache(t)))
+
+		_, first, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency})
+		require.NoError(t, err)
+		_, second, err := ret.Balances(rosBlockID, accountID, []identifier.Currency{currency})
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, calls)
+
+		// Sub-accounts have their own balances, so they should not be served
+		// from the cached balance of the main vault.
+		subAccountID := accountID
+		subAccountID.SubAccount = &identifier.SubAccount{Address: configuration.SubAccountLocked}
+
+		_, sub, err := ret.Balances(rosBlockID, subAccountID, []identifier.Currency{currency})
+		require.NoError(t, err)
+		require.Len(t, sub, 1)
+		assert.NotEqual(t, "42", sub[0].Value)
+	})
+
+	t.Run("handles sub-account with other currency", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.CurrencyFunc = func(identifier.Currency) (string, uint, error) {
+			return "TEST", 8, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		subAccountID := accountID
+		subAccountID.SubAccount = &identifier.SubAccount{Address: configuration.SubAccountLocked}
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			subAccountID,
+			[]identifier.Currency{{Symbol: "TEST", Decimals: 8}},
+		)
+		assert.ErrorAs(t, err, &failure.InvalidCurrency{})
+	})
+
+	t.Run("handles sub-account script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.GetStakedBalanceFunc = func() ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		subAccountID := accountID
+		subAccountID.SubAccount = &identifier.SubAccount{Address: configuration.SubAccountStaked}
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			subAccountID,
+			[]identifier.Currency{currency},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid block", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(identifier.Block) (uint64, flow.Identifier, error) {
+			return 0, flow.ZeroID, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			accountID,
+			[]identifier.Currency{currency},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid account", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.AccountFunc = func(identifier.Account) (flow.Address, error) {
+			return flow.EmptyAddress, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			accountID,
+			[]identifier.Currency{currency},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid currency", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.CurrencyFunc = func(identifier.Currency) (string, uint, error) {
+			return "", 0, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			accountID,
+			[]identifier.Currency{currency},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.GetBalancesFunc = func([]string) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+		generator.GetBalanceFunc = func(string) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			accountID,
+			[]identifier.Currency{currency},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invoker failure", func(t *testing.T) {
+		t.Parallel()
+
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoker))
+
+		_, _, err := ret.Balances(
+			rosBlockID,
+			accountID,
+			[]identifier.Currency{currency},
+		)
+		assert.Error(t, err)
+	})
+}
+
+func TestRetriever_BalanceHistory(t *testing.T) {
+	header := mocks.GenericHeader
+	account := mocks.GenericAccount
+	accountID := mocks.GenericAccountID(0)
+	currency := mocks.GenericCurrency
+
+	start := header.Height
+	end := header.Height + 3
+	startID := identifier.Block{Index: &start}
+	endID := identifier.Block{Index: &end}
+
+	withdrawalType := mocks.GenericEventType(0)
+	depositType := mocks.GenericEventType(1)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.AccountFunc = func(rosAccountID identifier.Account) (flow.Address, error) {
+			assert.Equal(t, accountID, rosAccountID)
+
+			return account.Address, nil
+		}
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			require.NotNil(t, rosBlockID.Index)
+
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
+			assert.Equal(t, currency.Symbol, symbol)
+
+			return string(withdrawalType), nil
+		}
+		generator.TokensDepositedFunc = func(symbol string) (string, error) {
+			assert.Equal(t, currency.Symbol, symbol)
+
+			return string(depositType), nil
+		}
+
+		// The account receives a deposit at the first height after the start, nothing
+		// happens at the second one and another account is involved at the third one.
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Equal(t, []flow.EventType{withdrawalType, depositType}, types)
+
+			switch height {
+			case start + 1:
+				return mocks.GenericEvents(1, depositType), nil
+			case start + 3:
+				return mocks.GenericEvents(1, withdrawalType), nil
+			default:
+				return []flow.Event{}, nil
+			}
+		}
+		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			assert.Equal(t, start+1, height)
+
+			return header, nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			op := mocks.GenericOperation(0)
+			if event.Type == withdrawalType {
+				op = mocks.GenericOperation(1)
+			}
+
+			return &op, nil
+		}
+
+		var heights []uint64
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(height uint64, script []byte, parameters []cadence.Value) (cadence.Value, error) {
+			heights = append(heights, height)
+
+			return mocks.GenericAmount(int(height - start)), nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+			retriever.WithInvoker(invoker),
+		)
+
+		points, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{start, start + 1}, heights)
+
+		require.Len(t, points, 2)
+		assert.Equal(t, start, *points[0].BlockID.Index)
+		assert.Equal(t, mocks.GenericAmount(0).String(), points[0].Value)
+		assert.Equal(t, start+1, *points[1].BlockID.Index)
+		assert.Equal(t, header.ID().String(), points[1].BlockID.Hash)
+		assert.Equal(t, mocks.GenericAmount(1).String(), points[1].Value)
+	})
+
+	t.Run("nominal case with unchanged balance", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(uint64) (*flow.Header, error) {
+			t.Fatal("header should not be retrieved for unchanged balance")
+			return nil, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator), retriever.WithIndex(index))
+
+		points, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		require.NoError(t, err)
+		require.Len(t, points, 1)
+		assert.Equal(t, start, *points[0].BlockID.Index)
+	})
+
+	t.Run("looks up events of the range concurrently", func(t *testing.T) {
+		t.Parallel()
+
+		last := start + 20
+		lastID := identifier.Block{Index: &last}
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		// The baseline retriever uses four workers, so there should never be more
+		// than four lookups in flight, and each height is looked up exactly once.
+		var mutex sync.Mutex
+		var running, peak int
+		lookups := make(map[uint64]int)
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(height uint64, _ ...flow.EventType) ([]flow.Event, error) {
+			mutex.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			lookups[height]++
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			return []flow.Event{}, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator), retriever.WithIndex(index))
+
+		points, err := ret.BalanceHistory(accountID, currency, startID, lastID)
+
+		require.NoError(t, err)
+		assert.Len(t, points, 1)
+		assert.LessOrEqual(t, peak, 4)
+		require.Len(t, lookups, 20)
+		for height := start + 1; height <= last; height++ {
+			assert.Equal(t, 1, lookups[height])
+		}
+	})
+
+	t.Run("nominal case with vault balances", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		vault := mocks.BaselineVault(t)
+		vault.BalanceFunc = func(height uint64, address flow.Address, symbol string) (uint64, bool, error) {
+			assert.Equal(t, account.Address, address)
+			assert.Equal(t, currency.Symbol, symbol)
+
+			return 7, true, nil
+		}
+
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			t.Fatal("balance script should not be used when the vault balance is available")
+			return nil, nil
+		}
+
+		ret := retriever.BaselineRetriever(t,
+			retriever.WithValidator(validator),
+			retriever.WithVault(vault),
+			retriever.WithInvoker(invoker),
+		)
+
+		points, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		require.NoError(t, err)
+		require.Len(t, points, 1)
+		assert.Equal(t, "7", points[0].Value)
+	})
+
+	t.Run("handles invalid account", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.AccountFunc = func(identifier.Account) (flow.Address, error) {
+			return flow.EmptyAddress, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles sub-account", func(t *testing.T) {
+		t.Parallel()
+
+		ret := retriever.BaselineRetriever(t)
+
+		subAccountID := accountID
+		subAccountID.SubAccount = &identifier.SubAccount{Address: configuration.SubAccountLocked}
+
+		_, err := ret.BalanceHistory(subAccountID, currency, startID, endID)
+
+		assert.ErrorAs(t, err, &failure.InvalidAccount{})
+	})
+
+	t.Run("handles invalid currency", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.CurrencyFunc = func(identifier.Currency) (string, uint, error) {
+			return "", 0, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid block", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(identifier.Block) (uint64, flow.Identifier, error) {
+			return 0, flow.ZeroID, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles inverted block range", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, err := ret.BalanceHistory(accountID, currency, endID, startID)
+
+		assert.ErrorAs(t, err, &failure.InvalidBlock{})
+	})
+
+	t.Run("handles block range exceeding limit", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator), retriever.WithHistory(3))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		assert.ErrorAs(t, err, &failure.InvalidBlock{})
+	})
+
+	t.Run("handles deposit script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, startID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invoker failure", func(t *testing.T) {
+		t.Parallel()
+
+		invoker := mocks.BaselineInvoker(t)
+		invoker.ScriptFunc = func(uint64, []byte, []cadence.Value) (cadence.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithInvoker(invoker))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, startID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index event retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator), retriever.WithIndex(index))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles converter failure", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			return *rosBlockID.Index, header.ID(), nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(flow.Event) (*object.Operation, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator), retriever.WithConverter(convert))
+
+		_, err := ret.BalanceHistory(accountID, currency, startID, endID)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRetriever_Block(t *testing.T) {
+	header := mocks.GenericHeader
+	rosBlockID := mocks.GenericRosBlockID
+
+	transactions := mocks.GenericTransactionIDs(5)
+	withdrawalType := mocks.GenericEventType(0)
+	depositType := mocks.GenericEventType(1)
+	withdrawals := mocks.GenericEvents(2, withdrawalType)
+	deposits := mocks.GenericEvents(2, depositType)
+	events := append(withdrawals, deposits...)
+
+	t.Run("nominal case with limit not reached", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			assert.Equal(t, header.Height, height)
+
+			return header, nil
+		}
+		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			assert.Equal(t, header.Height, height)
+
+			return transactions, nil
+		}
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Equal(t, header.Height, height)
+
+			return events, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			assert.Equal(t, rosBlockID, rosBlockID)
+
+			return header.Height, header.ID(), nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(symbol string) (string, error) {
+			assert.Equal(t, symbol, dps.FlowSymbol)
+
+			return string(withdrawalType), nil
+		}
+		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
+			assert.Equal(t, symbol, dps.FlowSymbol)
+
+			return string(depositType), nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			var op object.Operation
+			switch event.Type {
+			case withdrawalType:
+				assert.Contains(t, withdrawals, event)
+				op = mocks.GenericOperation(0)
+			case depositType:
+				assert.Contains(t, deposits, event)
+				op = mocks.GenericOperation(1)
+			}
+
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+			retriever.WithLimit(6),
+		)
+
+		block, extra, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosBlockID, block.ID)
+		assert.Len(t, block.Transactions, 5)
+
+		assert.Empty(t, extra)
+
+		commit := mocks.GenericCommit(0)
+		require.NotNil(t, block.Metadata)
+		assert.Equal(t, header.ProposerID.String(), block.Metadata.ProposerID)
+		assert.Equal(t, header.View, block.Metadata.View)
+		assert.Equal(t, hex.EncodeToString(commit[:]), block.Metadata.StateCommitment)
+		assert.Len(t, block.Metadata.Guarantees, 5)
+		assert.Len(t, block.Metadata.Seals, 5)
+	})
+
+	t.Run("nominal case with concurrent workers", func(t *testing.T) {
+		t.Parallel()
+
+		// Each transaction has a withdrawal and a deposit, which are listed in
+		// reverse order in the block events, to make sure that events are sorted
+		// and grouped correctly for each transaction.
+		txIDs := mocks.GenericTransactionIDs(20)
+		positions := make(map[flow.Identifier]int, len(txIDs))
+		var events []flow.Event
+		for position, txID := range txIDs {
+			positions[txID] = position
+			events = append(events,
+				flow.Event{TransactionID: txID, EventIndex: 1, Type: depositType},
+				flow.Event{TransactionID: txID, EventIndex: 0, Type: withdrawalType},
+			)
+		}
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+
+		// Earlier transactions take longer to look up, so that they finish
+		// building after later ones when they are built concurrently.
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return events, nil
+		}
+		index.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+			time.Sleep(time.Duration(len(txIDs)-positions[txID]) * time.Millisecond)
+			return &flow.TransactionResult{TransactionID: txID}, nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
+			return string(withdrawalType), nil
+		}
+
+		// Each operation is attributed to the transaction that emitted its event,
+		// so that we can check that it ends up in the right transaction.
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			op := mocks.GenericOperation(0)
+			op.AccountID = identifier.Account{Address: event.TransactionID.String()}
+			op.Type = string(event.Type)
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithConverter(convert),
+		)
+
+		block, extra, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		assert.Empty(t, extra)
+		require.Len(t, block.Transactions, len(txIDs))
+		for position, tx := range block.Transactions {
+			assert.Equal(t, txIDs[position].String(), tx.ID.Hash)
+			require.Len(t, tx.Operations, 2)
+			// Deposits have priority over withdrawals, regardless of event index.
+			assert.Equal(t, string(depositType), tx.Operations[0].Type)
+			assert.Equal(t, string(withdrawalType), tx.Operations[1].Type)
+			for opIndex, op := range tx.Operations {
+				assert.Equal(t, uint(opIndex), op.ID.Index)
+				assert.Equal(t, tx.ID.Hash, op.AccountID.Address)
+			}
+		}
+	})
+
+	t.Run("nominal case with limit reached exactly", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			assert.Equal(t, header.Height, height)
+
+			return mocks.GenericHeader, nil
+		}
+		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			assert.Equal(t, header.Height, height)
+
+			return mocks.GenericTransactionIDs(5), nil
+		}
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Equal(t, header.Height, height)
+
+			return events, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			assert.Equal(t, rosBlockID, rosBlockID)
+
+			return header.Height, header.ID(), nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(symbol string) (string, error) {
+			assert.Equal(t, symbol, dps.FlowSymbol)
+
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
+			assert.Equal(t, symbol, dps.FlowSymbol)
+
+			return string(withdrawalType), nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+
+			var op object.Operation
+			switch event.Type {
+			case withdrawalType:
+				assert.Contains(t, withdrawals, event)
+				op = mocks.GenericOperation(0)
+			case depositType:
+				assert.Contains(t, deposits, event)
+				op = mocks.GenericOperation(1)
+			}
+
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+			retriever.WithLimit(5),
+		)
+
+		block, extra, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		assert.Len(t, block.Transactions, 5)
+		assert.Equal(t, rosBlockID, block.ID)
+
+		assert.Empty(t, extra)
+	})
+
+	t.Run("nominal case with more transactions than limit", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			assert.Equal(t, header.Height, height)
+
+			return mocks.GenericHeader, nil
+		}
+		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			assert.Equal(t, header.Height, height)
+
+			return mocks.GenericTransactionIDs(6), nil
+		}
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Equal(t, mocks.GenericHeight, height)
+
+			return events, nil
+		}
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Equal(t, header.Height, height)
+
+			return events, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			assert.Equal(t, rosBlockID, rosBlockID)
+
+			return header.Height, header.ID(), nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(symbol string) (string, error) {
+			assert.Equal(t, symbol, dps.FlowSymbol)
+
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
+			assert.Equal(t, symbol, dps.FlowSymbol)
+
+			return string(withdrawalType), nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+
+			var op object.Operation
+			switch event.Type {
+			case withdrawalType:
+				assert.Contains(t, withdrawals, event)
+				op = mocks.GenericOperation(0)
+			case depositType:
+				assert.Contains(t, deposits, event)
+				op = mocks.GenericOperation(1)
+			}
+
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+			retriever.WithLimit(5),
+		)
+
+		block, extra, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosBlockID, block.ID)
+		assert.Len(t, block.Transactions, 5)
+
+		assert.Len(t, extra, 1)
+	})
+
+	t.Run("handles block without transactions", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return []flow.Identifier{}, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		got, _, err := ret.Block(rosBlockID)
+		require.NoError(t, err)
+		assert.Empty(t, got.Transactions)
+	})
+
+	t.Run("uses first block as its own parent", func(t *testing.T) {
+		t.Parallel()
+
+		ret := retriever.BaselineRetriever(t)
+
+		got, _, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosBlockID, got.ParentID)
+	})
+
+	t.Run("uses previous spork block as parent of first block", func(t *testing.T) {
+		t.Parallel()
+
+		previousHeight := header.Height - 1
+		previous := identifier.Block{
+			Index: &previousHeight,
+			Hash:  mocks.GenericHeader.ParentID.String(),
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithPreviousID(previous))
+
+		got, _, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, previous, got.ParentID)
+	})
+
+	t.Run("uses header parent for later blocks", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.FirstFunc = func() (uint64, error) {
+			return header.Height - 10, nil
+		}
+
+		previous := identifier.Block{
+			Index: mocks.GenericRosBlockID.Index,
+			Hash:  mocks.GenericRosBlockID.Hash,
+		}
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithPreviousID(previous))
+
+		got, _, err := ret.Block(rosBlockID)
+
+		require.NoError(t, err)
+		require.NotNil(t, got.ParentID.Index)
+		assert.Equal(t, header.Height-1, *got.ParentID.Index)
+		assert.Equal(t, header.ParentID.String(), got.ParentID.Hash)
+	})
+
+	t.Run("uses cached block", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(uint64) (*flow.Header, error) {
+			calls++
+			return header, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithCache(memoCache(t)))
+
+		first, firstExtra, err := ret.Block(rosBlockID)
+		require.NoError(t, err)
+		second, secondExtra, err := ret.Block(rosBlockID)
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, firstExtra, secondExtra)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("handles cache failure", func(t *testing.T) {
+		t.Parallel()
+
+		cache := mocks.BaselineResponseCache(t)
+		cache.GetFunc = func(string, func() (interface{}, error)) (interface{}, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithCache(cache))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles block without relevant events", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return []flow.Event{}, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		got, _, err := ret.Block(rosBlockID)
+		require.NoError(t, err)
+		require.NotEmpty(t, got.Transactions)
+		for _, tx := range got.Transactions {
+			assert.Empty(t, tx.Operations)
+		}
+	})
+
+	t.Run("handles invalid block", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(identifier.Block) (uint64, flow.Identifier, error) {
+			return 0, flow.ZeroID, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles deposit script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles withdrawal script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles fees script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.FeesDeductedFunc = func() (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles mint script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensMintedFunc = func(string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles staking script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.DelegatorRewardsPaidFunc = func() (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index header retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(uint64) (*flow.Header, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, err := ret.Block(rosBlockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index event retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, err := ret.Block(rosBlockID)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index commit retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, err := ret.Block(rosBlockID)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index guarantee retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.GuaranteeFunc = func(flow.Identifier) (*flow.CollectionGuarantee, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, err := ret.Block(rosBlockID)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index seals by height retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.SealsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, err := ret.Block(rosBlockID)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index seal retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.SealFunc = func(flow.Identifier) (*flow.Seal, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, err := ret.Block(rosBlockID)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles event converter failure", func(t *testing.T) {
+		t.Parallel()
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(flow.Event) (*object.Operation, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithConverter(convert))
+
+		_, _, err := ret.Block(rosBlockID)
+		assert.Error(t, err)
+	})
+}
+
+func TestRetriever_BlockTransactions(t *testing.T) {
+	header := mocks.GenericHeader
+	rosBlockID := mocks.GenericRosBlockID
+
+	transactions := mocks.GenericTransactionIDs(6)
+
+	t.Run("nominal case with first page", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			assert.Equal(t, header.Height, height)
+
+			return transactions, nil
+		}
+
+		var txIDs []flow.Identifier
+		index.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+			txIDs = append(txIDs, txID)
+
+			return mocks.GenericResult(0), nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithLimit(5))
+
+		blockID, got, next, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosBlockID, blockID)
+		require.Len(t, got, 2)
+		assert.Equal(t, transactions[0].String(), got[0].ID.Hash)
+		assert.Equal(t, transactions[1].String(), got[1].ID.Hash)
+		assert.Equal(t, transactions[:2], txIDs)
+		assert.Equal(t, uint(2), next)
+	})
+
+	t.Run("nominal case with last page", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithLimit(5))
+
+		_, got, next, err := ret.BlockTransactions(rosBlockID, 4, 3)
+
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, transactions[4].String(), got[0].ID.Hash)
+		assert.Equal(t, transactions[5].String(), got[1].ID.Hash)
+		assert.Zero(t, next)
+	})
+
+	t.Run("nominal case with limit capped by transaction limit", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithLimit(5))
+
+		_, got, next, err := ret.BlockTransactions(rosBlockID, 0, 10)
+
+		require.NoError(t, err)
+		assert.Len(t, got, 5)
+		assert.Equal(t, uint(5), next)
+	})
+
+	t.Run("nominal case with default limit", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithLimit(4))
+
+		_, got, next, err := ret.BlockTransactions(rosBlockID, 0, 0)
+
+		require.NoError(t, err)
+		assert.Len(t, got, 4)
+		assert.Equal(t, uint(4), next)
+	})
+
+	t.Run("only looks up collections of the page", func(t *testing.T) {
+		t.Parallel()
+
+		collIDs := mocks.GenericCollectionIDs(3)
+		collections := map[flow.Identifier]*flow.LightCollection{
+			collIDs[0]: {Transactions: transactions[0:2]},
+			collIDs[1]: {Transactions: transactions[2:4]},
+			collIDs[2]: {Transactions: transactions[4:6]},
+		}
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+		index.CollectionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return collIDs, nil
+		}
+		var lookups []flow.Identifier
+		index.CollectionFunc = func(collID flow.Identifier) (*flow.LightCollection, error) {
+			lookups = append(lookups, collID)
+			return collections[collID], nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithLimit(5))
+
+		_, got, _, err := ret.BlockTransactions(rosBlockID, 1, 2)
+
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, collIDs[:2], lookups)
+		require.NotNil(t, got[0].Metadata)
+		assert.Equal(t, collIDs[0].String(), got[0].Metadata.CollectionID)
+		require.NotNil(t, got[1].Metadata)
+		assert.Equal(t, collIDs[1].String(), got[1].Metadata.CollectionID)
+	})
+
+	t.Run("routes lookups by identifier to the spork of the block", func(t *testing.T) {
+		t.Parallel()
+
+		// Lookups by identifier on the index of all sporks fail the test, as the
+		// height of the block is known.
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+		index.CollectionFunc = func(flow.Identifier) (*flow.LightCollection, error) {
+			t.Fatal("collection should be looked up in the spork of the block")
+			return nil, nil
+		}
+		index.TransactionFunc = func(flow.Identifier) (*flow.TransactionBody, error) {
+			t.Fatal("transaction should be looked up in the spork of the block")
+			return nil, nil
+		}
+		index.ResultFunc = func(flow.Identifier) (*flow.TransactionResult, error) {
+			t.Fatal("result should be looked up in the spork of the block")
+			return nil, nil
+		}
+
+		var mutex sync.Mutex
+		var heights []uint64
+		routed := router{
+			Reader: index,
+			spork: func(height uint64) (dps.Reader, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				heights = append(heights, height)
+				return mocks.BaselineReader(t), nil
+			},
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(routed), retriever.WithLimit(5))
+
+		_, got, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		require.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.NotEmpty(t, heights)
+		for _, height := range heights {
+			assert.Equal(t, header.Height, height)
+		}
+	})
+
+	t.Run("handles zero transaction limit", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index), retriever.WithLimit(0))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 0)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles offset past the last transaction", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return transactions, nil
+		}
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			t.Fatal("events should not be retrieved for an empty page")
+			return nil, nil
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		blockID, got, next, err := ret.BlockTransactions(rosBlockID, 10, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosBlockID, blockID)
+		assert.NotNil(t, got)
+		assert.Empty(t, got)
+		assert.Zero(t, next)
+	})
+
+	t.Run("handles invalid block", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(identifier.Block) (uint64, flow.Identifier, error) {
+			return 0, flow.ZeroID, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index transactions by height failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles deposit script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index event retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index collections retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.CollectionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles converter failure", func(t *testing.T) {
+		t.Parallel()
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(flow.Event) (*object.Operation, error) {
+			return nil, mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithConverter(convert))
+
+		_, _, _, err := ret.BlockTransactions(rosBlockID, 0, 2)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRetriever_Transaction(t *testing.T) {
+	header := mocks.GenericHeader
+	rosBlockID := mocks.GenericRosBlockID
+
+	withdrawalType := mocks.GenericEventType(0)
+	depositType := mocks.GenericEventType(1)
+	withdrawals := mocks.GenericEvents(2, withdrawalType)
+	deposits := mocks.GenericEvents(2, depositType)
+	events := append(withdrawals, deposits...)
+
+	txQual := mocks.GenericTransactionQualifier(0)
+	txIDs := mocks.GenericTransactionIDs(5)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
+			assert.Equal(t, rosBlockID, rosBlockID)
+
+			return header.Height, header.ID(), nil
+		}
+		validator.TransactionFunc = func(transaction identifier.Transaction) (flow.Identifier, error) {
+			assert.Equal(t, txQual, transaction)
+
+			return txIDs[0], nil
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(symbol string) (string, error) {
+			assert.Equal(t, dps.FlowSymbol, symbol)
+
+			return string(withdrawalType), nil
+		}
+		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
+			assert.Equal(t, dps.FlowSymbol, symbol)
+
+			return string(depositType), nil
+		}
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Equal(t, header.Height, height)
+			require.Len(t, types, 12)
+			assert.Equal(t, withdrawalType, types[0])
+			assert.Equal(t, depositType, types[1])
+			assert.Equal(t, mocks.GenericEventType(10), types[2])
+			assert.Equal(t, mocks.GenericEventType(11), types[3])
+			assert.Equal(t, mocks.GenericEventType(2), types[4])
+
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			assert.Equal(t, header.Height, height)
+
+			return txIDs, nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+
+			var op object.Operation
+			switch event.Type {
+			case withdrawalType:
+				assert.Contains(t, withdrawals, event)
+				op = mocks.GenericOperation(0)
+			case depositType:
+				assert.Contains(t, deposits, event)
+				op = mocks.GenericOperation(1)
+			}
+
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
+
+		got, err := ret.Transaction(rosBlockID, txQual)
+
+		require.NoError(t, err)
+		assert.Equal(t, txQual, got.ID)
+		assert.Len(t, got.Operations, 2)
+	})
+
+	t.Run("nominal case with multiple tokens", func(t *testing.T) {
+		t.Parallel()
+
+		params := mocks.GenericParams
+		params.Tokens = map[string]dps.Token{
+			dps.FlowSymbol: {Symbol: dps.FlowSymbol},
+			"TEST":         {Symbol: "TEST"},
+		}
+
+		// Skip the event types that the baseline generator uses for fees and staking.
+		types := mocks.GenericEventTypes(17)[12:]
+		typeIndices := map[string]int{
+			dps.FlowSymbol: 0,
+			"TEST":         2,
+		}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(symbol string) (string, error) {
+			return string(types[typeIndices[symbol]]), nil
+		}
+		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
+			return string(types[typeIndices[symbol]+1]), nil
+		}
+		generator.FeesDeductedFunc = func() (string, error) {
+			return string(types[4]), nil
+		}
+
+		// The events are given out of order, across both tokens.
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: types[3], EventIndex: 0},
+			{TransactionID: txIDs[0], Type: types[1], EventIndex: 1},
+			{TransactionID: txIDs[0], Type: types[2], EventIndex: 2},
+			{TransactionID: txIDs[0], Type: types[0], EventIndex: 4},
+			{TransactionID: txIDs[0], Type: types[0], EventIndex: 3},
+		}
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			assert.Len(t, types, 16)
+
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(transaction identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID: identifier.Operation{NetworkIndex: &netIndex},
+			}
+
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithParams(params),
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
+
+		got, err := ret.Transaction(rosBlockID, txQual)
+
+		require.NoError(t, err)
+		require.Len(t, got.Operations, 5)
+
+		wantOrder := []uint{3, 4, 1, 2, 0}
+		for i, op := range got.Operations {
+			assert.Equal(t, uint(i), op.ID.Index)
+			require.NotNil(t, op.ID.NetworkIndex)
+			assert.Equal(t, wantOrder[i], *op.ID.NetworkIndex)
+		}
+	})
+
+	t.Run("nominal case with fees", func(t *testing.T) {
+		t.Parallel()
+
+		payer := mocks.GenericAddress(0).String()
+		other := mocks.GenericAddress(1).String()
+		fees := mocks.GenericAddress(2).String()
+
+		// The payer withdraws the fee amount twice, but only the withdrawal that
+		// precedes the fee event is the one that pays for the fee.
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 0},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 1},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 2},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 3},
+			{TransactionID: txIDs[0], Type: mocks.GenericEventType(2), EventIndex: 4},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 5},
+		}
+		accounts := []string{payer, other, payer, fees, "", payer}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
+			return string(withdrawalType), nil
+		}
+
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
+
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      dps.OperationTransfer,
+				AccountID: identifier.Account{Address: accounts[event.EventIndex]},
+				Amount:    &object.Amount{Value: "-42", Currency: mocks.GenericCurrency},
+			}
+			switch event.Type {
+			case depositType:
+				op.Amount.Value = "42"
+			case mocks.GenericEventType(2):
+				op.Type = configuration.OperationFee
+			}
+
+			return &op, nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
+
+		got, err := ret.Transaction(rosBlockID, txQual)
 
 		require.NoError(t, err)
-		assert.Equal(t, rosBlockID, block.ID)
-		assert.Len(t, block.Transactions, 5)
+		require.Len(t, got.Operations, 5)
 
-		assert.Empty(t, extra)
+		var feeOps []*object.Operation
+		for _, op := range got.Operations {
+			if op.Type == configuration.OperationFee {
+				feeOps = append(feeOps, op)
+			}
+		}
+		require.Len(t, feeOps, 1)
+		assert.Equal(t, payer, feeOps[0].AccountID.Address)
+		assert.Equal(t, "-42", feeOps[0].Amount.Value)
+		require.NotNil(t, feeOps[0].ID.NetworkIndex)
+		assert.Equal(t, uint(2), *feeOps[0].ID.NetworkIndex)
 	})
 
-	t.Run("nominal case with limit reached exactly", func(t *testing.T) {
+	t.Run("nominal case with mint and burn", func(t *testing.T) {
 		t.Parallel()
 
-		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
-			assert.Equal(t, header.Height, height)
+		mintType := mocks.GenericEventType(10)
+		burnType := mocks.GenericEventType(11)
 
-			return mocks.GenericHeader, nil
+		// The minted amount is deposited twice, but only the deposit that follows
+		// the mint event is the one that receives the minted tokens.
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: mintType, EventIndex: 0},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 1},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 2},
+			{TransactionID: txIDs[0], Type: burnType, EventIndex: 3},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 4},
 		}
-		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
-			assert.Equal(t, header.Height, height)
+		values := []string{"42", "42", "-7", "-7", "42"}
 
-			return mocks.GenericTransactionIDs(5), nil
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
+			return string(withdrawalType), nil
 		}
-		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
-			assert.Equal(t, header.Height, height)
 
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
 			return events, nil
 		}
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
 
 		validator := mocks.BaselineValidator(t)
-		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
-			assert.Equal(t, rosBlockID, rosBlockID)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
 
-			return header.Height, header.ID(), nil
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      dps.OperationTransfer,
+				AccountID: identifier.Account{Address: mocks.GenericAddress(int(event.EventIndex)).String()},
+				Amount:    &object.Amount{Value: values[event.EventIndex], Currency: mocks.GenericCurrency},
+			}
+			switch event.Type {
+			case mintType:
+				op.Type = configuration.OperationMint
+				op.AccountID = identifier.Account{}
+			case burnType:
+				op.Type = configuration.OperationBurn
+				op.AccountID = identifier.Account{}
+			}
+
+			return &op, nil
 		}
 
-		generator := mocks.BaselineGenerator(t)
-		generator.TokensDepositedFunc = func(symbol string) (string, error) {
-			assert.Equal(t, symbol, dps.FlowSymbol)
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
 
-			return string(depositType), nil
+		got, err := ret.Transaction(rosBlockID, txQual)
+
+		require.NoError(t, err)
+		require.Len(t, got.Operations, 3)
+
+		types := make(map[uint]string)
+		for _, op := range got.Operations {
+			require.NotNil(t, op.ID.NetworkIndex)
+			assert.NotEmpty(t, op.AccountID.Address)
+			types[*op.ID.NetworkIndex] = op.Type
 		}
-		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
-			assert.Equal(t, symbol, dps.FlowSymbol)
+		wantTypes := map[uint]string{
+			1: configuration.OperationMint,
+			2: configuration.OperationBurn,
+			4: dps.OperationTransfer,
+		}
+		assert.Equal(t, wantTypes, types)
+	})
+
+	t.Run("nominal case with failed transaction", func(t *testing.T) {
+		t.Parallel()
+
+		payer := mocks.GenericAddress(0).String()
+		fees := mocks.GenericAddress(1)
+
+		params := mocks.GenericParams
+		params.FlowFees = fees
+
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 0},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 1},
+			{TransactionID: txIDs[0], Type: mocks.GenericEventType(2), EventIndex: 2},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 3},
+		}
+		accounts := []string{payer, fees.String(), "", payer}
+		values := []string{"-42", "42", "-42", "-7"}
 
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
 			return string(withdrawalType), nil
 		}
 
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+		index.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+			assert.Equal(t, txIDs[0], txID)
+
+			result := mocks.GenericResult(0)
+			result.ErrorMessage = "execution reverted"
+
+			return result, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
+
 		convert := mocks.BaselineConverter(t)
 		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
-
-			var op object.Operation
-			switch event.Type {
-			case withdrawalType:
-				assert.Contains(t, withdrawals, event)
-				op = mocks.GenericOperation(0)
-			case depositType:
-				assert.Contains(t, deposits, event)
-				op = mocks.GenericOperation(1)
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      dps.OperationTransfer,
+				Status:    dps.StatusCompleted,
+				AccountID: identifier.Account{Address: accounts[event.EventIndex]},
+				Amount:    &object.Amount{Value: values[event.EventIndex], Currency: mocks.GenericCurrency},
+			}
+			if event.Type == mocks.GenericEventType(2) {
+				op.Type = configuration.OperationFee
 			}
 
 			return &op, nil
@@ -439,77 +2594,206 @@ func TestRetriever_Block(t *testing.T) {
 
 		ret := retriever.BaselineRetriever(
 			t,
+			retriever.WithParams(params),
 			retriever.WithGenerator(generator),
 			retriever.WithIndex(index),
 			retriever.WithValidator(validator),
 			retriever.WithConverter(convert),
-			retriever.WithLimit(5),
 		)
 
-		block, extra, err := ret.Block(rosBlockID)
+		got, err := ret.Transaction(rosBlockID, txQual)
 
 		require.NoError(t, err)
-		assert.Len(t, block.Transactions, 5)
-		assert.Equal(t, rosBlockID, block.ID)
+		require.Len(t, got.Operations, 3)
+		require.NotNil(t, got.Metadata)
+		assert.Equal(t, "execution reverted", got.Metadata.ErrorMessage)
 
-		assert.Empty(t, extra)
+		statuses := make(map[uint]string)
+		for _, op := range got.Operations {
+			require.NotNil(t, op.ID.NetworkIndex)
+			statuses[*op.ID.NetworkIndex] = op.Status
+		}
+		wantStatuses := map[uint]string{
+			0: dps.StatusCompleted,
+			1: dps.StatusCompleted,
+			3: configuration.StatusFailed.Status,
+		}
+		assert.Equal(t, wantStatuses, statuses)
 	})
 
-	t.Run("nominal case with more transactions than limit", func(t *testing.T) {
+	t.Run("failed transaction with unpaired fee", func(t *testing.T) {
 		t.Parallel()
 
-		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
-			assert.Equal(t, header.Height, height)
+		payer := mocks.GenericAddress(0)
+		fees := mocks.GenericAddress(1)
 
-			return mocks.GenericHeader, nil
+		params := mocks.GenericParams
+		params.FlowFees = fees
+
+		// The fee is emitted before the withdrawal from the payer, so it can not be
+		// paired with it, but the withdrawal is still related to the fee deposit.
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: mocks.GenericEventType(2), EventIndex: 0},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 1},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 2},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 3},
 		}
-		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
-			assert.Equal(t, header.Height, height)
+		accounts := []string{"", payer.String(), fees.String(), payer.String()}
+		values := []string{"-42", "-42", "42", "-7"}
 
-			return mocks.GenericTransactionIDs(6), nil
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
+			return string(withdrawalType), nil
 		}
-		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
-			assert.Equal(t, mocks.GenericHeight, height)
 
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
 			return events, nil
 		}
-		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
-			assert.Equal(t, header.Height, height)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+		index.TransactionFunc = func(txID flow.Identifier) (*flow.TransactionBody, error) {
+			assert.Equal(t, txIDs[0], txID)
 
-			return events, nil
+			tx := mocks.GenericTransaction(0)
+			tx.Payer = payer
+
+			return tx, nil
+		}
+		index.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+			assert.Equal(t, txIDs[0], txID)
+
+			result := mocks.GenericResult(0)
+			result.ErrorMessage = "execution reverted"
+
+			return result, nil
 		}
 
 		validator := mocks.BaselineValidator(t)
-		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
-			assert.Equal(t, rosBlockID, rosBlockID)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
 
-			return header.Height, header.ID(), nil
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      dps.OperationTransfer,
+				Status:    dps.StatusCompleted,
+				AccountID: identifier.Account{Address: accounts[event.EventIndex]},
+				Amount:    &object.Amount{Value: values[event.EventIndex], Currency: mocks.GenericCurrency},
+			}
+			if event.Type == mocks.GenericEventType(2) {
+				op.Type = configuration.OperationFee
+			}
+
+			return &op, nil
 		}
 
-		generator := mocks.BaselineGenerator(t)
-		generator.TokensDepositedFunc = func(symbol string) (string, error) {
-			assert.Equal(t, symbol, dps.FlowSymbol)
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithParams(params),
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
 
-			return string(depositType), nil
+		got, err := ret.Transaction(rosBlockID, txQual)
+
+		require.NoError(t, err)
+		require.Len(t, got.Operations, 3)
+		require.NotNil(t, got.Metadata)
+		assert.Equal(t, "execution reverted", got.Metadata.ErrorMessage)
+
+		statuses := make(map[uint]string)
+		for _, op := range got.Operations {
+			require.NotNil(t, op.ID.NetworkIndex)
+			statuses[*op.ID.NetworkIndex] = op.Status
 		}
-		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
-			assert.Equal(t, symbol, dps.FlowSymbol)
+		wantStatuses := map[uint]string{
+			1: dps.StatusCompleted,
+			2: dps.StatusCompleted,
+			3: configuration.StatusFailed.Status,
+		}
+		assert.Equal(t, wantStatuses, statuses)
+	})
+
+	t.Run("failed transaction with ambiguous fee pairing", func(t *testing.T) {
+		t.Parallel()
+
+		payer := mocks.GenericAddress(0)
+		fees := mocks.GenericAddress(1)
+		other := mocks.GenericAddress(2).String()
+
+		params := mocks.GenericParams
+		params.FlowFees = fees
 
+		// Another account withdraws the same amount before the payer, so it gets
+		// related to the fee deposit, but only the payer is charged for the fee.
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 0},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 1},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 2},
+			{TransactionID: txIDs[0], Type: mocks.GenericEventType(2), EventIndex: 3},
+		}
+		accounts := []string{other, payer.String(), fees.String(), ""}
+		values := []string{"-42", "-42", "42", "-42"}
+
+		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
+		generator.TokensWithdrawnFunc = func(string) (string, error) {
 			return string(withdrawalType), nil
 		}
 
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+		index.TransactionFunc = func(txID flow.Identifier) (*flow.TransactionBody, error) {
+			assert.Equal(t, txIDs[0], txID)
+
+			tx := mocks.GenericTransaction(0)
+			tx.Payer = payer
+
+			return tx, nil
+		}
+		index.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+			assert.Equal(t, txIDs[0], txID)
+
+			result := mocks.GenericResult(0)
+			result.ErrorMessage = "execution reverted"
+
+			return result, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
+
 		convert := mocks.BaselineConverter(t)
 		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
-
-			var op object.Operation
-			switch event.Type {
-			case withdrawalType:
-				assert.Contains(t, withdrawals, event)
-				op = mocks.GenericOperation(0)
-			case depositType:
-				assert.Contains(t, deposits, event)
-				op = mocks.GenericOperation(1)
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      dps.OperationTransfer,
+				Status:    dps.StatusCompleted,
+				AccountID: identifier.Account{Address: accounts[event.EventIndex]},
+				Amount:    &object.Amount{Value: values[event.EventIndex], Currency: mocks.GenericCurrency},
+			}
+			if event.Type == mocks.GenericEventType(2) {
+				op.Type = configuration.OperationFee
 			}
 
 			return &op, nil
@@ -517,209 +2801,360 @@ func TestRetriever_Block(t *testing.T) {
 
 		ret := retriever.BaselineRetriever(
 			t,
-			retriever.WithGenerator(generator),
+			retriever.WithParams(params),
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
+
+		got, err := ret.Transaction(rosBlockID, txQual)
+
+		require.NoError(t, err)
+		require.Len(t, got.Operations, 3)
+		require.NotNil(t, got.Metadata)
+		assert.Equal(t, "execution reverted", got.Metadata.ErrorMessage)
+
+		statuses := make(map[uint]string)
+		for _, op := range got.Operations {
+			require.NotNil(t, op.ID.NetworkIndex)
+			statuses[*op.ID.NetworkIndex] = op.Status
+		}
+		wantStatuses := map[uint]string{
+			0: configuration.StatusFailed.Status,
+			1: dps.StatusCompleted,
+			2: dps.StatusCompleted,
+		}
+		assert.Equal(t, wantStatuses, statuses)
+	})
+
+	t.Run("uses cached transaction", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			calls++
+			return txIDs, nil
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithCache(memoCache(t)),
+		)
+
+		first, err := ret.Transaction(rosBlockID, txQual)
+		require.NoError(t, err)
+		second, err := ret.Transaction(rosBlockID, txQual)
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("handles index result retrieval failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+		index.ResultFunc = func(flow.Identifier) (*flow.TransactionResult, error) {
+			return nil, mocks.GenericError
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
+
+		ret := retriever.BaselineRetriever(
+			t,
 			retriever.WithIndex(index),
 			retriever.WithValidator(validator),
-			retriever.WithConverter(convert),
-			retriever.WithLimit(5),
 		)
 
-		block, extra, err := ret.Block(rosBlockID)
-
-		require.NoError(t, err)
-		assert.Equal(t, rosBlockID, block.ID)
-		assert.Len(t, block.Transactions, 5)
+		_, err := ret.Transaction(rosBlockID, txQual)
 
-		assert.Len(t, extra, 1)
+		assert.Error(t, err)
 	})
 
-	t.Run("handles block without transactions", func(t *testing.T) {
+	t.Run("nominal case with transaction metadata", func(t *testing.T) {
 		t.Parallel()
 
+		tx := mocks.GenericTransaction(0)
+		tx.Payer = mocks.GenericAddress(0)
+		tx.ProposalKey = flow.ProposalKey{
+			Address:        mocks.GenericAddress(1),
+			KeyIndex:       3,
+			SequenceNumber: 42,
+		}
+		tx.Authorizers = []flow.Address{mocks.GenericAddress(2), mocks.GenericAddress(3)}
+		tx.GasLimit = 9999
+		tx.Script = []byte("transaction {}")
+
+		collID := mocks.GenericCollectionIDs(1)[0]
+
 		index := mocks.BaselineReader(t)
 		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
-			return []flow.Identifier{}, nil
+			return txIDs, nil
 		}
+		index.CollectionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			assert.Equal(t, header.Height, height)
 
-		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
-
-		got, _, err := ret.Block(rosBlockID)
-		require.NoError(t, err)
-		assert.Empty(t, got.Transactions)
-	})
+			return []flow.Identifier{collID}, nil
+		}
+		index.CollectionFunc = func(id flow.Identifier) (*flow.LightCollection, error) {
+			assert.Equal(t, collID, id)
 
-	t.Run("handles block without relevant events", func(t *testing.T) {
-		t.Parallel()
+			return &flow.LightCollection{Transactions: txIDs[:2]}, nil
+		}
+		index.TransactionFunc = func(txID flow.Identifier) (*flow.TransactionBody, error) {
+			assert.Equal(t, txIDs[0], txID)
 
-		index := mocks.BaselineReader(t)
-		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
-			return []flow.Event{}, nil
+			return tx, nil
 		}
+		index.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+			assert.Equal(t, txIDs[0], txID)
 
-		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+			result := mocks.GenericResult(0)
+			result.ComputationUsed = 1337
 
-		got, _, err := ret.Block(rosBlockID)
-		require.NoError(t, err)
-		require.NotEmpty(t, got.Transactions)
-		for _, tx := range got.Transactions {
-			assert.Empty(t, tx.Operations)
+			return result, nil
 		}
-	})
-
-	t.Run("handles invalid block", func(t *testing.T) {
-		t.Parallel()
 
 		validator := mocks.BaselineValidator(t)
-		validator.BlockFunc = func(identifier.Block) (uint64, flow.Identifier, error) {
-			return 0, flow.ZeroID, mocks.GenericError
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithValidator(validator))
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+		)
 
-		_, _, err := ret.Block(rosBlockID)
+		got, err := ret.Transaction(rosBlockID, txQual)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		require.NotNil(t, got.Metadata)
+
+		want := object.TransactionMetadata{
+			Payer:            mocks.GenericAddress(0).String(),
+			Proposer:         mocks.GenericAddress(1).String(),
+			ProposalKeyIndex: 3,
+			SequenceNumber:   42,
+			Authorizers:      []string{mocks.GenericAddress(2).String(), mocks.GenericAddress(3).String()},
+			GasLimit:         9999,
+			ComputationUsed:  1337,
+			ReferenceBlockID: tx.ReferenceBlockID.String(),
+			CollectionID:     collID.String(),
+			// SHA3-256 hash of the script.
+			ScriptHash: "f54a7041590e2f606db318fbc37cbe588c0b5dfcd9eb072254d183141a115607",
+		}
+		assert.Equal(t, &want, got.Metadata)
 	})
 
-	t.Run("handles deposit script generate failure", func(t *testing.T) {
+	t.Run("handles index collection retrieval failure", func(t *testing.T) {
 		t.Parallel()
 
-		generator := mocks.BaselineGenerator(t)
-		generator.TokensDepositedFunc = func(string) (string, error) {
-			return "", mocks.GenericError
+		index := mocks.BaselineReader(t)
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
+		index.CollectionFunc = func(flow.Identifier) (*flow.LightCollection, error) {
+			return nil, mocks.GenericError
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
 
-		_, _, err := ret.Block(rosBlockID)
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+		)
+
+		_, err := ret.Transaction(rosBlockID, txQual)
 
 		assert.Error(t, err)
 	})
 
-	t.Run("handles withdrawal script generate failure", func(t *testing.T) {
+	t.Run("nominal case with related operations", func(t *testing.T) {
 		t.Parallel()
 
+		// The second withdrawal is split into two deposits.
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 0},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 1},
+			{TransactionID: txIDs[0], Type: withdrawalType, EventIndex: 2},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 3},
+			{TransactionID: txIDs[0], Type: depositType, EventIndex: 4},
+		}
+		values := []string{"-10", "10", "-10", "4", "6"}
+
 		generator := mocks.BaselineGenerator(t)
+		generator.TokensDepositedFunc = func(string) (string, error) {
+			return string(depositType), nil
+		}
 		generator.TokensWithdrawnFunc = func(string) (string, error) {
-			return "", mocks.GenericError
+			return string(withdrawalType), nil
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
-
-		_, _, err := ret.Block(rosBlockID)
+		index := mocks.BaselineReader(t)
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
+		}
 
-		assert.Error(t, err)
-	})
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
 
-	t.Run("handles index header retrieval failure", func(t *testing.T) {
-		t.Parallel()
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      dps.OperationTransfer,
+				AccountID: identifier.Account{Address: mocks.GenericAddress(int(event.EventIndex)).String()},
+				Amount:    &object.Amount{Value: values[event.EventIndex], Currency: mocks.GenericCurrency},
+			}
 
-		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(uint64) (*flow.Header, error) {
-			return nil, mocks.GenericError
+			return &op, nil
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithGenerator(generator),
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
 
-		_, _, err := ret.Block(rosBlockID)
+		got, err := ret.Transaction(rosBlockID, txQual)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		require.Len(t, got.Operations, 5)
+
+		// Deposits come before withdrawals, so the withdrawals reference the deposits.
+		related := make(map[uint][]uint)
+		for _, op := range got.Operations {
+			for _, relatedID := range op.RelatedIDs {
+				assert.Less(t, relatedID.Index, op.ID.Index)
+				related[*op.ID.NetworkIndex] = append(related[*op.ID.NetworkIndex], *relatedID.NetworkIndex)
+			}
+		}
+		wantRelated := map[uint][]uint{
+			0: {1},
+			2: {3, 4},
+		}
+		assert.Equal(t, wantRelated, related)
 	})
 
-	t.Run("handles index event retrieval failure", func(t *testing.T) {
+	t.Run("nominal case with account creation", func(t *testing.T) {
 		t.Parallel()
 
+		payer := mocks.GenericAddress(0)
+		created := mocks.GenericAddress(1)
+
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: mocks.GenericEventType(9), EventIndex: 0},
+		}
+
 		index := mocks.BaselineReader(t)
 		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
-			return nil, mocks.GenericError
+			return events, nil
+		}
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
+			return txIDs, nil
 		}
+		index.TransactionFunc = func(txID flow.Identifier) (*flow.TransactionBody, error) {
+			assert.Equal(t, txIDs[0], txID)
 
-		ret := retriever.BaselineRetriever(t, retriever.WithIndex(index))
+			tx := mocks.GenericTransaction(0)
+			tx.Payer = payer
 
-		_, _, err := ret.Block(rosBlockID)
-		assert.Error(t, err)
-	})
+			return tx, nil
+		}
 
-	t.Run("handles event converter failure", func(t *testing.T) {
-		t.Parallel()
-		convert := mocks.BaselineConverter(t)
-		convert.EventToOperationFunc = func(flow.Event) (*object.Operation, error) {
-			return nil, mocks.GenericError
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
 		}
 
-		ret := retriever.BaselineRetriever(t, retriever.WithConverter(convert))
+		convert := mocks.BaselineConverter(t)
+		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
+			netIndex := uint(event.EventIndex)
+			op := object.Operation{
+				ID:        identifier.Operation{NetworkIndex: &netIndex},
+				Type:      configuration.OperationCreateAccount,
+				AccountID: identifier.Account{Address: created.String()},
+			}
 
-		_, _, err := ret.Block(rosBlockID)
-		assert.Error(t, err)
-	})
-}
+			return &op, nil
+		}
 
-func TestRetriever_Transaction(t *testing.T) {
-	header := mocks.GenericHeader
-	rosBlockID := mocks.GenericRosBlockID
+		ret := retriever.BaselineRetriever(
+			t,
+			retriever.WithIndex(index),
+			retriever.WithValidator(validator),
+			retriever.WithConverter(convert),
+		)
 
-	withdrawalType := mocks.GenericEventType(0)
-	depositType := mocks.GenericEventType(1)
-	withdrawals := mocks.GenericEvents(2, withdrawalType)
-	deposits := mocks.GenericEvents(2, depositType)
-	events := append(withdrawals, deposits...)
+		got, err := ret.Transaction(rosBlockID, txQual)
 
-	txQual := mocks.GenericTransactionQualifier(0)
-	txIDs := mocks.GenericTransactionIDs(5)
+		require.NoError(t, err)
+		require.Len(t, got.Operations, 1)
+
+		op := got.Operations[0]
+		assert.Equal(t, configuration.OperationCreateAccount, op.Type)
+		assert.Equal(t, created.String(), op.AccountID.Address)
+		assert.Nil(t, op.Amount)
+		require.NotNil(t, op.Metadata)
+		assert.Equal(t, payer.String(), op.Metadata.Payer)
+	})
 
-	t.Run("nominal case", func(t *testing.T) {
+	t.Run("handles index transaction retrieval failure for account creation", func(t *testing.T) {
 		t.Parallel()
 
-		validator := mocks.BaselineValidator(t)
-		validator.BlockFunc = func(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
-			assert.Equal(t, rosBlockID, rosBlockID)
-
-			return header.Height, header.ID(), nil
-		}
-		validator.TransactionFunc = func(transaction identifier.Transaction) (flow.Identifier, error) {
-			assert.Equal(t, txQual, transaction)
-
-			return txIDs[0], nil
-		}
-
-		generator := mocks.BaselineGenerator(t)
-		generator.TokensDepositedFunc = func(symbol string) (string, error) {
-			assert.Equal(t, dps.FlowSymbol, symbol)
-
-			return string(withdrawalType), nil
-		}
-		generator.TokensWithdrawnFunc = func(symbol string) (string, error) {
-			assert.Equal(t, dps.FlowSymbol, symbol)
-
-			return string(depositType), nil
+		events := []flow.Event{
+			{TransactionID: txIDs[0], Type: mocks.GenericEventType(9), EventIndex: 0},
 		}
 
 		index := mocks.BaselineReader(t)
-		index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
-			assert.Equal(t, header.Height, height)
-			require.Len(t, types, 2)
-			assert.Equal(t, withdrawalType, types[0])
-			assert.Equal(t, depositType, types[1])
-
+		index.EventsFunc = func(uint64, ...flow.EventType) ([]flow.Event, error) {
 			return events, nil
 		}
-		index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
-			assert.Equal(t, header.Height, height)
-
+		index.TransactionsByHeightFunc = func(uint64) ([]flow.Identifier, error) {
 			return txIDs, nil
 		}
+		index.TransactionFunc = func(flow.Identifier) (*flow.TransactionBody, error) {
+			return nil, mocks.GenericError
+		}
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return txIDs[0], nil
+		}
 
 		convert := mocks.BaselineConverter(t)
 		convert.EventToOperationFunc = func(event flow.Event) (*object.Operation, error) {
-
-			var op object.Operation
-			switch event.Type {
-			case withdrawalType:
-				assert.Contains(t, withdrawals, event)
-				op = mocks.GenericOperation(0)
-			case depositType:
-				assert.Contains(t, deposits, event)
-				op = mocks.GenericOperation(1)
+			op := object.Operation{
+				Type:      configuration.OperationCreateAccount,
+				AccountID: identifier.Account{Address: mocks.GenericAddress(1).String()},
 			}
 
 			return &op, nil
@@ -727,17 +3162,14 @@ func TestRetriever_Transaction(t *testing.T) {
 
 		ret := retriever.BaselineRetriever(
 			t,
-			retriever.WithGenerator(generator),
 			retriever.WithIndex(index),
 			retriever.WithValidator(validator),
 			retriever.WithConverter(convert),
 		)
 
-		got, err := ret.Transaction(rosBlockID, txQual)
+		_, err := ret.Transaction(rosBlockID, txQual)
 
-		require.NoError(t, err)
-		assert.Equal(t, txQual, got.ID)
-		assert.Len(t, got.Operations, 2)
+		assert.Error(t, err)
 	})
 
 	t.Run("handles transaction with no relevant operations", func(t *testing.T) {
@@ -848,6 +3280,21 @@ func TestRetriever_Transaction(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("handles fees script generate failure", func(t *testing.T) {
+		t.Parallel()
+
+		generator := mocks.BaselineGenerator(t)
+		generator.FeesDeductedFunc = func() (string, error) {
+			return "", mocks.GenericError
+		}
+
+		ret := retriever.BaselineRetriever(t, retriever.WithGenerator(generator))
+
+		_, err := ret.Transaction(rosBlockID, mocks.GenericTransactionQualifier(0))
+
+		assert.Error(t, err)
+	})
+
 	t.Run("handles index event retrieval failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -916,7 +3363,9 @@ func TestRetriever_Sequence(t *testing.T) {
 			validator,
 			mocks.BaselineGenerator(t),
 			invoker,
+			mocks.BaselineVault(t),
 			mocks.BaselineConverter(t),
+			mocks.BaselineResponseCache(t),
 		)
 
 		seqNum, err := ret.Sequence(rosBlockID, accountID, 0)
@@ -939,7 +3388,9 @@ func TestRetriever_Sequence(t *testing.T) {
 			validator,
 			mocks.BaselineGenerator(t),
 			mocks.BaselineInvoker(t),
+			mocks.BaselineVault(t),
 			mocks.BaselineConverter(t),
+			mocks.BaselineResponseCache(t),
 		)
 
 		_, err := ret.Sequence(rosBlockID, accountID, 0)
@@ -961,7 +3412,9 @@ func TestRetriever_Sequence(t *testing.T) {
 			validator,
 			mocks.BaselineGenerator(t),
 			mocks.BaselineInvoker(t),
+			mocks.BaselineVault(t),
 			mocks.BaselineConverter(t),
+			mocks.BaselineResponseCache(t),
 		)
 
 		_, err := ret.Sequence(rosBlockID, accountID, 0)
@@ -983,7 +3436,9 @@ func TestRetriever_Sequence(t *testing.T) {
 			mocks.BaselineValidator(t),
 			mocks.BaselineGenerator(t),
 			invoker,
+			mocks.BaselineVault(t),
 			mocks.BaselineConverter(t),
+			mocks.BaselineResponseCache(t),
 		)
 
 		_, err := ret.Sequence(rosBlockID, accountID, 0)
@@ -991,3 +3446,43 @@ func TestRetriever_Sequence(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// router is an index that routes lookups by identifier to the index returned by
+// its spork function.
+type router struct {
+	*mocks.Reader
+	spork func(height uint64) (dps.Reader, error)
+}
+
+func (r router) Spork(height uint64) (dps.Reader, error) {
+	return r.spork(height)
+}
+
+// memoCache returns a cache mock that keeps every loaded value, so that each key
+// is only loaded once.
+func memoCache(t *testing.T) *mocks.ResponseCache {
+	t.Helper()
+
+	var mutex sync.Mutex
+	values := make(map[string]interface{})
+
+	cache := mocks.BaselineResponseCache(t)
+	cache.GetFunc = func(key string, load func() (interface{}, error)) (interface{}, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		value, ok := values[key]
+		if ok {
+			return value, nil
+		}
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+
+		return value, nil
+	}
+
+	return cache
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package retriever
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Vault represents something that can read the token balance of an account directly
+// from the execution state, as long as its token vault is set up the standard way.
+type Vault interface {
+	Balance(height uint64, address flow.Address, symbol string) (uint64, bool, error)
+}
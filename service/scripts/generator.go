@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"text/template"
 
+	"github.com/onflow/flow-go/model/flow"
+
 	"github.com/optakt/flow-dps/models/dps"
 )
 
@@ -26,9 +28,28 @@ import (
 type Generator struct {
 	params          dps.Params
 	getBalance      *template.Template
+	getBalances     *template.Template
 	transferTokens  *template.Template
 	tokensDeposited *template.Template
 	tokensWithdrawn *template.Template
+	tokensMinted    *template.Template
+	tokensBurned    *template.Template
+	feesDeducted    *template.Template
+	accountCreated  *template.Template
+
+	tokensCommitted          *template.Template
+	tokensUnstaked           *template.Template
+	rewardsPaid              *template.Template
+	delegatorTokensCommitted *template.Template
+	delegatorTokensUnstaked  *template.Template
+	delegatorRewardsPaid     *template.Template
+
+	getLockedBalance    *template.Template
+	getStakedBalance    *template.Template
+	getDelegatedBalance *template.Template
+	getStorageReserved  *template.Template
+
+	getStakedNodes *template.Template
 }
 
 // NewGenerator returns a Generator using the given parameters.
@@ -36,9 +57,28 @@ func NewGenerator(params dps.Params) *Generator {
 	g := Generator{
 		params:          params,
 		getBalance:      template.Must(template.New("get_balance").Parse(getBalance)),
+		getBalances:     template.Must(template.New("get_balances").Parse(getBalances)),
 		transferTokens:  template.Must(template.New("transfer_tokens").Parse(transferTokens)),
 		tokensDeposited: template.Must(template.New("tokensDeposited").Parse(tokensDeposited)),
 		tokensWithdrawn: template.Must(template.New("withdrawal").Parse(tokensWithdrawn)),
+		tokensMinted:    template.Must(template.New("tokens_minted").Parse(tokensMinted)),
+		tokensBurned:    template.Must(template.New("tokens_burned").Parse(tokensBurned)),
+		feesDeducted:    template.Must(template.New("fees_deducted").Parse(feesDeducted)),
+		accountCreated:  template.Must(template.New("account_created").Parse(accountCreated)),
+
+		tokensCommitted:          template.Must(template.New("tokensCommitted").Parse(tokensCommitted)),
+		tokensUnstaked:           template.Must(template.New("tokensUnstaked").Parse(tokensUnstaked)),
+		rewardsPaid:              template.Must(template.New("rewardsPaid").Parse(rewardsPaid)),
+		delegatorTokensCommitted: template.Must(template.New("delegatorTokensCommitted").Parse(delegatorTokensCommitted)),
+		delegatorTokensUnstaked:  template.Must(template.New("delegatorTokensUnstaked").Parse(delegatorTokensUnstaked)),
+		delegatorRewardsPaid:     template.Must(template.New("delegatorRewardsPaid").Parse(delegatorRewardsPaid)),
+
+		getLockedBalance:    template.Must(template.New("get_locked_balance").Parse(getLockedBalance)),
+		getStakedBalance:    template.Must(template.New("get_staked_balance").Parse(getStakedBalance)),
+		getDelegatedBalance: template.Must(template.New("get_delegated_balance").Parse(getDelegatedBalance)),
+		getStorageReserved:  template.Must(template.New("get_storage_reserved").Parse(getStorageReserved)),
+
+		getStakedNodes: template.Must(template.New("get_staked_nodes").Parse(getStakedNodes)),
 	}
 	return &g
 }
@@ -48,6 +88,35 @@ func (g *Generator) GetBalance(symbol string) ([]byte, error) {
 	return g.bytes(g.getBalance, symbol)
 }
 
+// GetBalances generates a Cadence script to retrieve the balances of an account for multiple tokens at once.
+// The script returns a dictionary of balances by token symbol, where the balance of a token is zero if the
+// account does not have a vault for it.
+func (g *Generator) GetBalances(symbols []string) ([]byte, error) {
+
+	tokens := make(map[string]dps.Token, len(symbols))
+	for _, symbol := range symbols {
+		token, ok := g.params.Tokens[symbol]
+		if !ok {
+			return nil, fmt.Errorf("invalid token symbol (%s)", symbol)
+		}
+		tokens[symbol] = token
+	}
+	data := struct {
+		Params dps.Params
+		Tokens map[string]dps.Token
+	}{
+		Params: g.params,
+		Tokens: tokens,
+	}
+	buf := &bytes.Buffer{}
+	err := g.getBalances.Execute(buf, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
 // TransferTokens generates a Cadence script to operate a token transfer transaction.
 func (g *Generator) TransferTokens(symbol string) ([]byte, error) {
 	return g.bytes(g.transferTokens, symbol)
@@ -63,6 +132,88 @@ func (g *Generator) TokensWithdrawn(symbol string) (string, error) {
 	return g.string(g.tokensWithdrawn, symbol)
 }
 
+// TokensMinted generates a Cadence script that matches the Flow event for tokens being minted.
+func (g *Generator) TokensMinted(symbol string) (string, error) {
+	return g.string(g.tokensMinted, symbol)
+}
+
+// TokensBurned generates a Cadence script that matches the Flow event for tokens being burned.
+func (g *Generator) TokensBurned(symbol string) (string, error) {
+	return g.string(g.tokensBurned, symbol)
+}
+
+// FeesDeducted generates a Cadence script that matches the Flow event for transaction fees being deducted.
+// Transaction fees are always paid in the native Flow token.
+func (g *Generator) FeesDeducted() (string, error) {
+	return g.string(g.feesDeducted, dps.FlowSymbol)
+}
+
+// AccountCreated generates a Cadence script that matches the Flow event for an account being created.
+func (g *Generator) AccountCreated() (string, error) {
+	return g.string(g.accountCreated, dps.FlowSymbol)
+}
+
+// Staking is always done with the native Flow token, so the staking events are
+// generated using its symbol.
+
+// TokensCommitted generates a Cadence script that matches the Flow event for tokens being committed to a staking node.
+func (g *Generator) TokensCommitted() (string, error) {
+	return g.string(g.tokensCommitted, dps.FlowSymbol)
+}
+
+// TokensUnstaked generates a Cadence script that matches the Flow event for tokens being unstaked from a staking node.
+func (g *Generator) TokensUnstaked() (string, error) {
+	return g.string(g.tokensUnstaked, dps.FlowSymbol)
+}
+
+// RewardsPaid generates a Cadence script that matches the Flow event for rewards being paid to a staking node.
+func (g *Generator) RewardsPaid() (string, error) {
+	return g.string(g.rewardsPaid, dps.FlowSymbol)
+}
+
+// DelegatorTokensCommitted generates a Cadence script that matches the Flow event for tokens being committed to a delegator.
+func (g *Generator) DelegatorTokensCommitted() (string, error) {
+	return g.string(g.delegatorTokensCommitted, dps.FlowSymbol)
+}
+
+// DelegatorTokensUnstaked generates a Cadence script that matches the Flow event for tokens being unstaked from a delegator.
+func (g *Generator) DelegatorTokensUnstaked() (string, error) {
+	return g.string(g.delegatorTokensUnstaked, dps.FlowSymbol)
+}
+
+// DelegatorRewardsPaid generates a Cadence script that matches the Flow event for rewards being paid to a delegator.
+func (g *Generator) DelegatorRewardsPaid() (string, error) {
+	return g.string(g.delegatorRewardsPaid, dps.FlowSymbol)
+}
+
+// Sub-account balances are always held in the native Flow token, so their
+// scripts are generated using its symbol.
+
+// GetLockedBalance generates a Cadence script to retrieve the balance of the locked account linked to an account.
+func (g *Generator) GetLockedBalance() ([]byte, error) {
+	return g.bytes(g.getLockedBalance, dps.FlowSymbol)
+}
+
+// GetStakedBalance generates a Cadence script to retrieve the tokens of the nodes in an account's staking collection.
+func (g *Generator) GetStakedBalance() ([]byte, error) {
+	return g.bytes(g.getStakedBalance, dps.FlowSymbol)
+}
+
+// GetDelegatedBalance generates a Cadence script to retrieve the tokens of the delegators in an account's staking collection.
+func (g *Generator) GetDelegatedBalance() ([]byte, error) {
+	return g.bytes(g.getDelegatedBalance, dps.FlowSymbol)
+}
+
+// GetStorageReserved generates a Cadence script to retrieve the part of an account's balance reserved for its storage.
+func (g *Generator) GetStorageReserved() ([]byte, error) {
+	return g.bytes(g.getStorageReserved, dps.FlowSymbol)
+}
+
+// GetStakedNodes generates a Cadence script to retrieve the info of all nodes staked in the current epoch.
+func (g *Generator) GetStakedNodes() ([]byte, error) {
+	return g.bytes(g.getStakedNodes, dps.FlowSymbol)
+}
+
 func (g *Generator) string(template *template.Template, symbol string) (string, error) {
 	buf, err := g.compile(template, symbol)
 	if err != nil {
@@ -85,11 +236,13 @@ func (g *Generator) compile(template *template.Template, symbol string) (*bytes.
 		return nil, fmt.Errorf("invalid token symbol (%s)", symbol)
 	}
 	data := struct {
-		Params dps.Params
-		Token  dps.Token
+		Params  dps.Params
+		Token   dps.Token
+		Service flow.Address
 	}{
-		Params: g.params,
-		Token:  token,
+		Params:  g.params,
+		Token:   token,
+		Service: g.params.ChainID.Chain().ServiceAddress(),
 	}
 	buf := &bytes.Buffer{}
 	err := template.Execute(buf, data)
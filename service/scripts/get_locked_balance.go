@@ -0,0 +1,38 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package scripts
+
+// Adopted from:
+// https://github.com/onflow/flow-core-contracts/blob/master/transactions/lockedTokens/user/get_locked_account_balance.cdc
+
+const getLockedBalance = `// This script reads the balance of the locked account linked to an account
+
+import LockedTokens from 0x{{.Params.LockedTokens}}
+
+pub fun main(account: Address): UFix64 {
+
+    let lockedAccountInfoRef = getAccount(account)
+        .getCapability<&LockedTokens.TokenHolder{LockedTokens.LockedAccountInfo}>(
+            LockedTokens.LockedAccountInfoPublicPath
+        )
+        .borrow()
+
+    if lockedAccountInfoRef == nil {
+        return 0.0
+    }
+
+    return lockedAccountInfoRef!.getLockedAccountBalance()
+}
+`
@@ -0,0 +1,62 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package scripts
+
+// Adopted from:
+// https://github.com/onflow/flow-core-contracts/blob/master/transactions/stakingCollection/scripts/get_all_node_info.cdc
+// https://github.com/onflow/flow-core-contracts/blob/master/transactions/stakingCollection/scripts/get_all_delegator_info.cdc
+
+// The staking collection contract is deployed on the same account as the
+// locked tokens contract.
+
+const getStakedBalance = `// This script sums up the tokens of all nodes in an account's staking collection
+
+import FlowIDTableStaking from 0x{{.Params.StakingTable}}
+import FlowStakingCollection from 0x{{.Params.LockedTokens}}
+
+pub fun main(account: Address): UFix64 {
+
+    if !FlowStakingCollection.doesAccountHaveStakingCollection(address: account) {
+        return 0.0
+    }
+
+    var balance: UFix64 = 0.0
+    for info in FlowStakingCollection.getAllNodeInfo(address: account) {
+        balance = balance + info.tokensCommitted + info.tokensStaked + info.tokensUnstaking + info.tokensUnstaked + info.tokensRewarded
+    }
+
+    return balance
+}
+`
+
+const getDelegatedBalance = `// This script sums up the tokens of all delegators in an account's staking collection
+
+import FlowIDTableStaking from 0x{{.Params.StakingTable}}
+import FlowStakingCollection from 0x{{.Params.LockedTokens}}
+
+pub fun main(account: Address): UFix64 {
+
+    if !FlowStakingCollection.doesAccountHaveStakingCollection(address: account) {
+        return 0.0
+    }
+
+    var balance: UFix64 = 0.0
+    for info in FlowStakingCollection.getAllDelegatorInfo(address: account) {
+        balance = balance + info.tokensCommitted + info.tokensStaked + info.tokensUnstaking + info.tokensUnstaked + info.tokensRewarded
+    }
+
+    return balance
+}
+`
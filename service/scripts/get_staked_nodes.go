@@ -0,0 +1,34 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package scripts
+
+// Adopted from:
+// https://github.com/onflow/flow-core-contracts/blob/master/transactions/idTableStaking/scripts/get_table.cdc
+// https://github.com/onflow/flow-core-contracts/blob/master/transactions/idTableStaking/scripts/get_node_info.cdc
+
+const getStakedNodes = `// This script reads the info of all nodes staked in the current epoch
+
+import FlowIDTableStaking from 0x{{.Params.StakingTable}}
+
+pub fun main(): [FlowIDTableStaking.NodeInfo] {
+
+    let nodes: [FlowIDTableStaking.NodeInfo] = []
+    for nodeID in FlowIDTableStaking.getStakedNodeIDs() {
+        nodes.append(FlowIDTableStaking.NodeInfo(nodeID: nodeID))
+    }
+
+    return nodes
+}
+`
@@ -0,0 +1,50 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package scripts
+
+// Adopted from:
+// https://github.com/onflow/flow-core-contracts/blob/master/contracts/FlowStorageFees.cdc
+
+// The storage fees contract is deployed on the service account.
+
+const getStorageReserved = `// This script reads the part of an account's balance that is reserved for its storage
+
+import FungibleToken from 0x{{.Params.FungibleToken}}
+import {{.Token.Type}} from 0x{{.Token.Address}}
+import FlowStorageFees from 0x{{.Service}}
+
+pub fun main(account: Address): UFix64 {
+
+    let acct = getAccount(account)
+    let vaultRef = acct
+        .getCapability({{.Token.Balance}})
+        .borrow<&{{.Token.Type}}.Vault{FungibleToken.Balance}>()
+
+    if vaultRef == nil {
+        return 0.0
+    }
+
+    let used = FlowStorageFees.convertUInt64StorageBytesToUFix64Megabytes(acct.storageUsed)
+    var reserved = FlowStorageFees.storageCapacityToFlow(used)
+    if reserved < FlowStorageFees.minimumStorageReservation {
+        reserved = FlowStorageFees.minimumStorageReservation
+    }
+    if reserved > vaultRef!.balance {
+        return vaultRef!.balance
+    }
+
+    return reserved
+}
+`
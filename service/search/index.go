@@ -0,0 +1,481 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/failure"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Key prefixes of the search index. Apart from the height of the next block to
+// index, all keys end with the height and the ID of a transaction, so that the
+// transactions matching a key prefix are sorted by height.
+const (
+	prefixNext        = 0 // height of the next block to index
+	prefixTransaction = 1 // height and ID of all transactions
+	prefixHash        = 2 // ID of all transactions, to look up their height
+	prefixAccount     = 3 // address of each account involved in the operations of a transaction
+	prefixType        = 4 // type of each operation of a transaction
+)
+
+// Error description for queries on sub-accounts, which can not be searched, as
+// transactions are only indexed by the address of the accounts involved.
+const subAccountSearch = "search by sub-account is not supported"
+
+// Index is a local secondary index of the transactions of the DPS index, which
+// can be searched by account, transaction ID and operation type. It is built by
+// following the DPS index, and it persists the height of the next block to
+// index, so that it can continue where it left off after a restart.
+type Index struct {
+	cfg Config
+
+	db       *badger.DB
+	index    dps.Reader
+	validate Validator
+	retrieve Retriever
+
+	// update makes sure that only one update indexes blocks at a time.
+	update sync.Mutex
+	next   uint64
+}
+
+// New creates a new search index, which is stored in the given database and
+// follows the blocks of the given index, using the given retriever to get the
+// operations of their transactions.
+func New(db *badger.DB, index dps.Reader, validate Validator, retrieve Retriever, options ...func(*Config)) (*Index, error) {
+
+	cfg := Config{
+		Limit: 100,
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	i := Index{
+		cfg:      cfg,
+		db:       db,
+		index:    index,
+		validate: validate,
+		retrieve: retrieve,
+	}
+
+	err := db.View(func(tx *badger.Txn) error {
+		item, err := tx.Get([]byte{prefixNext})
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			i.next = binary.BigEndian.Uint64(val)
+			return nil
+		})
+	})
+	if err == nil {
+		return &i, nil
+	}
+	if !errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, fmt.Errorf("could not read next height: %w", err)
+	}
+
+	first, err := index.First()
+	if err != nil {
+		return nil, fmt.Errorf("could not get first height: %w", err)
+	}
+	i.next = first
+
+	return &i, nil
+}
+
+// Update indexes the transactions of each block that was indexed by DPS since
+// the last update. Each block is committed on its own, and the update stops
+// between two blocks once the given context is canceled.
+func (i *Index) Update(ctx context.Context) error {
+
+	i.update.Lock()
+	defer i.update.Unlock()
+
+	last, err := i.index.Last()
+	if err != nil {
+		return fmt.Errorf("could not get last height: %w", err)
+	}
+
+	for height := i.next; height <= last; height++ {
+
+		err = ctx.Err()
+		if err != nil {
+			return fmt.Errorf("could not finish update: %w", err)
+		}
+
+		err = i.block(height)
+		if err != nil {
+			return fmt.Errorf("could not index block (height: %d): %w", height, err)
+		}
+
+		i.next = height + 1
+	}
+
+	return nil
+}
+
+// Transactions returns the transactions matching the given query, from the most
+// recent to the oldest, as well as the number of matching transactions. If the
+// limit of the query is zero or above the configured limit, the configured limit
+// is used instead. Queries for the account of a sub-account are rejected rather
+// than matching all transactions of its parent account.
+//
+// In order to bound the cost of a query, the index stops looking for matching
+// transactions as soon as it knows whether there are more of them after the
+// returned page. The returned number is thus capped at the end of the page plus
+// one, and it is only exact if it is below that cap.
+func (i *Index) Transactions(query Query) ([]object.BlockTransaction, uint, error) {
+
+	var address *flow.Address
+	if query.AccountID != nil {
+		if query.AccountID.SubAccount != nil {
+			return nil, 0, failure.InvalidAccount{
+				Address: query.AccountID.Address,
+				Description: failure.NewDescription(subAccountSearch,
+					failure.WithString("sub_account", query.AccountID.SubAccount.Address),
+				),
+			}
+		}
+		validated, err := i.validate.Account(*query.AccountID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not validate account: %w", err)
+		}
+		address = &validated
+	}
+
+	var wantID *flow.Identifier
+	if query.TransactionID != nil {
+		validated, err := i.validate.Transaction(*query.TransactionID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not validate transaction: %w", err)
+		}
+		wantID = &validated
+	}
+
+	max := ^uint64(0)
+	if query.MaxBlock != nil {
+		max = *query.MaxBlock
+	}
+
+	limit := query.Limit
+	if limit == 0 || limit > i.cfg.Limit {
+		limit = i.cfg.Limit
+	}
+
+	// The filters are checked on each transaction that is a candidate for the
+	// query, and only the transactions of the requested page are kept. Once the
+	// total exceeds the end of the page, there are more matching transactions
+	// after it and we can stop.
+	var total uint
+	end := query.Offset + limit
+	var page []match
+	err := i.db.View(func(tx *badger.Txn) error {
+
+		check := func(height uint64, txID flow.Identifier, success bool) error {
+			if height > max {
+				return nil
+			}
+			if query.Success != nil && *query.Success != success {
+				return nil
+			}
+			if address != nil {
+				ok, err := exists(tx, accountKey(*address, height, txID))
+				if err != nil || !ok {
+					return err
+				}
+			}
+			if query.Type != "" {
+				ok, err := exists(tx, typeKey(query.Type, height, txID))
+				if err != nil || !ok {
+					return err
+				}
+			}
+
+			total++
+			if total <= query.Offset || uint(len(page)) >= limit {
+				return nil
+			}
+
+			val, err := value(tx, transactionKey(height, txID))
+			if err != nil {
+				return fmt.Errorf("could not get transaction (height: %d, tx: %x): %w", height, txID, err)
+			}
+			var blockID flow.Identifier
+			copy(blockID[:], val[1:])
+			page = append(page, match{height: height, blockID: blockID, txID: txID})
+
+			return nil
+		}
+
+		// If the transaction ID is given, it is the only candidate.
+		if wantID != nil {
+			val, err := value(tx, hashKey(*wantID))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("could not get transaction height (tx: %x): %w", *wantID, err)
+			}
+			height := binary.BigEndian.Uint64(val)
+			val, err = value(tx, transactionKey(height, *wantID))
+			if err != nil {
+				return fmt.Errorf("could not get transaction (height: %d, tx: %x): %w", height, *wantID, err)
+			}
+			return check(height, *wantID, val[0] == 1)
+		}
+
+		// Otherwise, the candidates are the transactions of the given account,
+		// or the transactions with the given operation type, or all of the
+		// transactions, from the given maximum height down.
+		prefix := []byte{prefixTransaction}
+		switch {
+		case address != nil:
+			prefix = accountPrefix(*address)
+		case query.Type != "":
+			prefix = typePrefix(query.Type)
+		}
+
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		opts.Prefix = prefix
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// When iterating in reverse, seeking to the largest possible key at the
+		// maximum height skips all of the transactions above it.
+		var last flow.Identifier
+		copy(last[:], bytes.Repeat([]byte{0xff}, len(last)))
+		for it.Seek(entryKey(prefix, max, last)); it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.Key()
+			height := binary.BigEndian.Uint64(key[len(prefix) : len(prefix)+8])
+			var txID flow.Identifier
+			copy(txID[:], key[len(prefix)+8:])
+			var success bool
+			err := item.Value(func(val []byte) error {
+				success = val[0] == 1
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("could not read candidate (height: %d, tx: %x): %w", height, txID, err)
+			}
+			err = check(height, txID, success)
+			if err != nil {
+				return err
+			}
+			if total > end {
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not search transactions: %w", err)
+	}
+
+	transactions := make([]object.BlockTransaction, 0, len(page))
+	for _, match := range page {
+		height := match.height
+		rosBlockID := identifier.Block{
+			Index: &height,
+			Hash:  match.blockID.String(),
+		}
+		rosTxID := identifier.Transaction{
+			Hash: match.txID.String(),
+		}
+		transaction, err := i.retrieve.Transaction(rosBlockID, rosTxID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not retrieve transaction (height: %d, tx: %x): %w", height, match.txID, err)
+		}
+		blockTransaction := object.BlockTransaction{
+			BlockID:     rosBlockID,
+			Transaction: transaction,
+		}
+		transactions = append(transactions, blockTransaction)
+	}
+
+	return transactions, total, nil
+}
+
+// block indexes the transactions of the block at the given height, along with
+// the accounts and types of their operations.
+func (i *Index) block(height uint64) error {
+
+	var rosBlockID identifier.Block
+	var transactions []*object.Transaction
+	offset := uint(0)
+	for {
+		var page []*object.Transaction
+		var next uint
+		var err error
+		rosBlockID, page, next, err = i.retrieve.BlockTransactions(identifier.Block{Index: &height}, offset, 0)
+		if err != nil {
+			return fmt.Errorf("could not retrieve block transactions (offset: %d): %w", offset, err)
+		}
+		transactions = append(transactions, page...)
+		if next == 0 {
+			break
+		}
+		if next <= offset {
+			return fmt.Errorf("could not retrieve block transactions (offset: %d): next offset %d does not advance", offset, next)
+		}
+		offset = next
+	}
+
+	blockID, err := flow.HexStringToIdentifier(rosBlockID.Hash)
+	if err != nil {
+		return fmt.Errorf("could not decode block ID: %w", err)
+	}
+
+	err = i.db.Update(func(tx *badger.Txn) error {
+
+		for _, transaction := range transactions {
+
+			txID, err := flow.HexStringToIdentifier(transaction.ID.Hash)
+			if err != nil {
+				return fmt.Errorf("could not decode transaction ID: %w", err)
+			}
+
+			// Transactions that failed during execution have an error message
+			// in their metadata.
+			success := byte(1)
+			if transaction.Metadata != nil && transaction.Metadata.ErrorMessage != "" {
+				success = 0
+			}
+
+			// Fee, mint and burn operations are not associated with an account,
+			// so they are only indexed by type.
+			addresses := make(map[flow.Address]struct{})
+			types := make(map[string]struct{})
+			for _, op := range transaction.Operations {
+				types[op.Type] = struct{}{}
+				if op.AccountID.Address == "" {
+					continue
+				}
+				addresses[flow.HexToAddress(op.AccountID.Address)] = struct{}{}
+			}
+
+			err = tx.Set(transactionKey(height, txID), append([]byte{success}, blockID[:]...))
+			if err != nil {
+				return fmt.Errorf("could not set transaction (tx: %x): %w", txID, err)
+			}
+			err = tx.Set(hashKey(txID), encodeHeight(height))
+			if err != nil {
+				return fmt.Errorf("could not set transaction height (tx: %x): %w", txID, err)
+			}
+			for address := range addresses {
+				err = tx.Set(accountKey(address, height, txID), []byte{success})
+				if err != nil {
+					return fmt.Errorf("could not set transaction account (tx: %x, address: %s): %w", txID, address, err)
+				}
+			}
+			for typ := range types {
+				err = tx.Set(typeKey(typ, height, txID), []byte{success})
+				if err != nil {
+					return fmt.Errorf("could not set transaction type (tx: %x, type: %s): %w", txID, typ, err)
+				}
+			}
+		}
+
+		return tx.Set([]byte{prefixNext}, encodeHeight(height+1))
+	})
+	if err != nil {
+		return fmt.Errorf("could not commit block: %w", err)
+	}
+
+	return nil
+}
+
+// match is a transaction that matches a query.
+type match struct {
+	height  uint64
+	blockID flow.Identifier
+	txID    flow.Identifier
+}
+
+func exists(tx *badger.Txn, key []byte) (bool, error) {
+	_, err := tx.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func value(tx *badger.Txn, key []byte) ([]byte, error) {
+	item, err := tx.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+func encodeHeight(height uint64) []byte {
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, height)
+	return val
+}
+
+func transactionKey(height uint64, txID flow.Identifier) []byte {
+	return entryKey([]byte{prefixTransaction}, height, txID)
+}
+
+func hashKey(txID flow.Identifier) []byte {
+	return append([]byte{prefixHash}, txID[:]...)
+}
+
+func accountPrefix(address flow.Address) []byte {
+	return append([]byte{prefixAccount}, address[:]...)
+}
+
+func accountKey(address flow.Address, height uint64, txID flow.Identifier) []byte {
+	return entryKey(accountPrefix(address), height, txID)
+}
+
+func typePrefix(typ string) []byte {
+	prefix := []byte{prefixType, byte(len(typ))}
+	return append(prefix, typ...)
+}
+
+func typeKey(typ string, height uint64, txID flow.Identifier) []byte {
+	return entryKey(typePrefix(typ), height, txID)
+}
+
+func entryKey(prefix []byte, height uint64, txID flow.Identifier) []byte {
+	key := make([]byte, 0, len(prefix)+8+len(txID))
+	key = append(key, prefix...)
+	key = append(key, encodeHeight(height)...)
+	key = append(key, txID[:]...)
+	return key
+}
@@ -0,0 +1,547 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package search_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
+	"github.com/optakt/flow-dps-rosetta/service/failure"
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
+	"github.com/optakt/flow-dps-rosetta/service/search"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+// The test chain has the following transactions, where the transaction at
+// height 10 with a fee operation failed during execution, and where the fee
+// operation is not associated with an account, like on a real chain:
+//
+//	height 10: tx 1 (transfer from account 0 to account 1), tx 2 (failed, fee paid by account 2)
+//	height 11: tx 3 (transfer from account 1 to account 2)
+//	height 12: tx 4 (no operations)
+var (
+	first = uint64(10)
+	last  = uint64(12)
+)
+
+func TestIndex_Update(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index, retrieve := chain(t)
+
+		s, err := search.New(inMemoryDB(t), index, validator(t), retrieve)
+		require.NoError(t, err)
+
+		err = s.Update(context.Background())
+		require.NoError(t, err)
+
+		got, total, err := s.Transactions(search.Query{})
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), total)
+		assert.Equal(t, []string{txHash(4), txHash(3), txHash(2), txHash(1)}, hashes(got))
+
+		// Transactions are returned with the identifier of their block.
+		require.NotNil(t, got[0].BlockID.Index)
+		assert.Equal(t, last, *got[0].BlockID.Index)
+		assert.Equal(t, blockID(last).String(), got[0].BlockID.Hash)
+	})
+
+	t.Run("continues after restart", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		index, retrieve := chain(t)
+
+		indexed := make(map[uint64]int)
+		blockTransactions := retrieve.BlockTransactionsFunc
+		retrieve.BlockTransactionsFunc = func(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error) {
+			if offset == 0 {
+				indexed[*rosBlockID.Index]++
+			}
+			return blockTransactions(rosBlockID, offset, limit)
+		}
+
+		index.LastFunc = func() (uint64, error) {
+			return 11, nil
+		}
+
+		db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		require.NoError(t, err)
+		s, err := search.New(db, index, validator(t), retrieve)
+		require.NoError(t, err)
+		require.NoError(t, s.Update(context.Background()))
+		require.NoError(t, db.Close())
+
+		index.LastFunc = func() (uint64, error) {
+			return last, nil
+		}
+
+		db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		s, err = search.New(db, index, validator(t), retrieve)
+		require.NoError(t, err)
+		require.NoError(t, s.Update(context.Background()))
+
+		assert.Equal(t, map[uint64]int{10: 1, 11: 1, 12: 1}, indexed)
+
+		_, total, err := s.Transactions(search.Query{})
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), total)
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		index, retrieve := chain(t)
+		blockTransactions := retrieve.BlockTransactionsFunc
+		retrieve.BlockTransactionsFunc = func(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error) {
+			cancel()
+			return blockTransactions(rosBlockID, offset, limit)
+		}
+
+		s, err := search.New(inMemoryDB(t), index, validator(t), retrieve)
+		require.NoError(t, err)
+
+		err = s.Update(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// The block that was being indexed when the update was canceled is
+		// still committed.
+		_, total, err := s.Transactions(search.Query{})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index, retrieve := chain(t)
+		index.LastFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		s, err := search.New(inMemoryDB(t), index, validator(t), retrieve)
+		require.NoError(t, err)
+
+		err = s.Update(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("handles retriever failure", func(t *testing.T) {
+		t.Parallel()
+
+		index, retrieve := chain(t)
+		retrieve.BlockTransactionsFunc = func(identifier.Block, uint, uint) (identifier.Block, []*object.Transaction, uint, error) {
+			return identifier.Block{}, nil, 0, mocks.GenericError
+		}
+
+		s, err := search.New(inMemoryDB(t), index, validator(t), retrieve)
+		require.NoError(t, err)
+
+		err = s.Update(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("handles pagination that does not advance", func(t *testing.T) {
+		t.Parallel()
+
+		index, retrieve := chain(t)
+		blockTransactions := retrieve.BlockTransactionsFunc
+		var calls int
+		retrieve.BlockTransactionsFunc = func(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error) {
+			calls++
+			rosBlockID, _, _, err := blockTransactions(rosBlockID, offset, limit)
+			return rosBlockID, []*object.Transaction{}, 1, err
+		}
+
+		s, err := search.New(inMemoryDB(t), index, validator(t), retrieve)
+		require.NoError(t, err)
+
+		err = s.Update(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestIndex_Transactions(t *testing.T) {
+
+	index, retrieve := chain(t)
+
+	s, err := search.New(inMemoryDB(t), index, validator(t), retrieve, search.WithLimit(3))
+	require.NoError(t, err)
+	require.NoError(t, s.Update(context.Background()))
+
+	yes := true
+	no := false
+	max := uint64(10)
+
+	tests := []struct {
+		name string
+
+		query search.Query
+
+		wantHashes []string
+		wantTotal  uint
+	}{
+		{
+			name:       "account",
+			query:      search.Query{AccountID: account(1)},
+			wantHashes: []string{txHash(3), txHash(1)},
+			wantTotal:  2,
+		},
+		{
+			name:       "account with maximum block",
+			query:      search.Query{AccountID: account(1), MaxBlock: &max},
+			wantHashes: []string{txHash(1)},
+			wantTotal:  1,
+		},
+		{
+			name:       "account with success",
+			query:      search.Query{AccountID: account(2), Success: &yes},
+			wantHashes: []string{txHash(3)},
+			wantTotal:  1,
+		},
+		{
+			name:       "account with type of operation without account",
+			query:      search.Query{AccountID: account(2), Type: configuration.OperationFee},
+			wantHashes: []string{txHash(2)},
+			wantTotal:  1,
+		},
+		{
+			name:       "account without transactions",
+			query:      search.Query{AccountID: account(3)},
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "zero address",
+			query:      search.Query{AccountID: &identifier.Account{Address: flow.EmptyAddress.String()}},
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "transaction",
+			query:      search.Query{TransactionID: transaction(3)},
+			wantHashes: []string{txHash(3)},
+			wantTotal:  1,
+		},
+		{
+			name:       "transaction without operations",
+			query:      search.Query{TransactionID: transaction(4)},
+			wantHashes: []string{txHash(4)},
+			wantTotal:  1,
+		},
+		{
+			name:       "transaction with mismatching account",
+			query:      search.Query{TransactionID: transaction(3), AccountID: account(0)},
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "transaction above maximum block",
+			query:      search.Query{TransactionID: transaction(3), MaxBlock: &max},
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "unknown transaction",
+			query:      search.Query{TransactionID: transaction(5)},
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "type",
+			query:      search.Query{Type: configuration.OperationTransfer},
+			wantHashes: []string{txHash(3), txHash(2), txHash(1)},
+			wantTotal:  3,
+		},
+		{
+			name:       "unknown type",
+			query:      search.Query{Type: configuration.OperationMint},
+			wantHashes: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "failed transactions",
+			query:      search.Query{Success: &no},
+			wantHashes: []string{txHash(2)},
+			wantTotal:  1,
+		},
+		{
+			name:       "offset and limit",
+			query:      search.Query{Offset: 1, Limit: 2},
+			wantHashes: []string{txHash(3), txHash(2)},
+			wantTotal:  4,
+		},
+		{
+			name:       "total capped after page",
+			query:      search.Query{Limit: 1},
+			wantHashes: []string{txHash(4)},
+			wantTotal:  2,
+		},
+		{
+			name:       "total capped after page with offset",
+			query:      search.Query{Offset: 1, Limit: 1},
+			wantHashes: []string{txHash(3)},
+			wantTotal:  3,
+		},
+		{
+			name:       "limit above configured limit",
+			query:      search.Query{Limit: 10},
+			wantHashes: []string{txHash(4), txHash(3), txHash(2)},
+			wantTotal:  4,
+		},
+		{
+			name:       "offset beyond matches",
+			query:      search.Query{Offset: 4},
+			wantHashes: []string{},
+			wantTotal:  4,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, total, err := s.Transactions(test.query)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.wantTotal, total)
+			assert.Equal(t, test.wantHashes, hashes(got))
+		})
+	}
+
+	t.Run("handles invalid account", func(t *testing.T) {
+		t.Parallel()
+
+		validate := validator(t)
+		validate.AccountFunc = func(identifier.Account) (flow.Address, error) {
+			return flow.EmptyAddress, mocks.GenericError
+		}
+
+		s, err := search.New(inMemoryDB(t), index, validate, retrieve)
+		require.NoError(t, err)
+
+		_, _, err = s.Transactions(search.Query{AccountID: account(0)})
+		assert.Error(t, err)
+	})
+
+	t.Run("handles sub-account", func(t *testing.T) {
+		t.Parallel()
+
+		accountID := account(0)
+		accountID.SubAccount = &identifier.SubAccount{Address: accountID.Address}
+
+		_, _, err := s.Transactions(search.Query{AccountID: accountID})
+		assert.ErrorAs(t, err, &failure.InvalidAccount{})
+	})
+
+	t.Run("handles invalid transaction", func(t *testing.T) {
+		t.Parallel()
+
+		validate := validator(t)
+		validate.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return flow.ZeroID, mocks.GenericError
+		}
+
+		s, err := search.New(inMemoryDB(t), index, validate, retrieve)
+		require.NoError(t, err)
+
+		_, _, err = s.Transactions(search.Query{TransactionID: transaction(1)})
+		assert.Error(t, err)
+	})
+
+	t.Run("handles retriever failure", func(t *testing.T) {
+		t.Parallel()
+
+		index, retrieve := chain(t)
+
+		s, err := search.New(inMemoryDB(t), index, validator(t), retrieve)
+		require.NoError(t, err)
+		require.NoError(t, s.Update(context.Background()))
+
+		retrieve.TransactionFunc = func(identifier.Block, identifier.Transaction) (*object.Transaction, error) {
+			return nil, mocks.GenericError
+		}
+
+		_, _, err = s.Transactions(search.Query{})
+		assert.Error(t, err)
+	})
+}
+
+// chain returns an index and a retriever for the test chain. The retriever
+// returns a single transaction per page, so that blocks are indexed over
+// multiple pages.
+func chain(t *testing.T) (*mocks.Reader, *mocks.Retriever) {
+	t.Helper()
+
+	transactions := map[uint64][]*object.Transaction{
+		10: {
+			{
+				ID: identifier.Transaction{Hash: txHash(1)},
+				Operations: []*object.Operation{
+					operation(0, configuration.OperationTransfer),
+					operation(1, configuration.OperationTransfer),
+				},
+			},
+			{
+				ID: identifier.Transaction{Hash: txHash(2)},
+				Operations: []*object.Operation{
+					operation(2, configuration.OperationTransfer),
+					{Type: configuration.OperationFee},
+				},
+				Metadata: &object.TransactionMetadata{ErrorMessage: "failed"},
+			},
+		},
+		11: {
+			{
+				ID: identifier.Transaction{Hash: txHash(3)},
+				Operations: []*object.Operation{
+					operation(1, configuration.OperationTransfer),
+					operation(2, configuration.OperationTransfer),
+				},
+				Metadata: &object.TransactionMetadata{},
+			},
+		},
+		12: {
+			{
+				ID: identifier.Transaction{Hash: txHash(4)},
+			},
+		},
+	}
+
+	index := mocks.BaselineReader(t)
+	index.FirstFunc = func() (uint64, error) {
+		return first, nil
+	}
+	index.LastFunc = func() (uint64, error) {
+		return last, nil
+	}
+
+	retrieve := mocks.BaselineRetriever(t)
+	retrieve.BlockTransactionsFunc = func(rosBlockID identifier.Block, offset uint, _ uint) (identifier.Block, []*object.Transaction, uint, error) {
+		height := *rosBlockID.Index
+		rosBlockID = identifier.Block{
+			Index: &height,
+			Hash:  blockID(height).String(),
+		}
+		block := transactions[height]
+		if offset >= uint(len(block)) {
+			return rosBlockID, []*object.Transaction{}, 0, nil
+		}
+		next := offset + 1
+		if next == uint(len(block)) {
+			next = 0
+		}
+		return rosBlockID, block[offset : offset+1], next, nil
+	}
+	retrieve.TransactionFunc = func(rosBlockID identifier.Block, rosTxID identifier.Transaction) (*object.Transaction, error) {
+		for _, transaction := range transactions[*rosBlockID.Index] {
+			if transaction.ID == rosTxID {
+				assert.Equal(t, blockID(*rosBlockID.Index).String(), rosBlockID.Hash)
+				return transaction, nil
+			}
+		}
+		t.Fatalf("unexpected transaction (%s)", rosTxID.Hash)
+		return nil, nil
+	}
+
+	return index, retrieve
+}
+
+func validator(t *testing.T) *mocks.Validator {
+	t.Helper()
+
+	validate := mocks.BaselineValidator(t)
+	validate.AccountFunc = func(rosAccountID identifier.Account) (flow.Address, error) {
+		return flow.HexToAddress(rosAccountID.Address), nil
+	}
+	validate.TransactionFunc = func(rosTxID identifier.Transaction) (flow.Identifier, error) {
+		return flow.HexStringToIdentifier(rosTxID.Hash)
+	}
+
+	return validate
+}
+
+func operation(account int, typ string) *object.Operation {
+	op := object.Operation{
+		AccountID: identifier.Account{Address: mocks.GenericAddress(account).String()},
+		Type:      typ,
+	}
+	return &op
+}
+
+func account(index int) *identifier.Account {
+	rosAccountID := identifier.Account{
+		Address: mocks.GenericAddress(index).String(),
+	}
+	return &rosAccountID
+}
+
+func transaction(index byte) *identifier.Transaction {
+	rosTxID := identifier.Transaction{
+		Hash: txHash(index),
+	}
+	return &rosTxID
+}
+
+// txHash returns the hash of a test transaction. The hashes are increasing,
+// so that the order of transactions within the same block is predictable.
+func txHash(index byte) string {
+	return flow.Identifier{index}.String()
+}
+
+func blockID(height uint64) flow.Identifier {
+	header := flow.Header{Height: height}
+	return header.ID()
+}
+
+func hashes(transactions []object.BlockTransaction) []string {
+	hashes := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		hashes = append(hashes, transaction.Transaction.ID.Hash)
+	}
+	return hashes
+}
+
+func inMemoryDB(t *testing.T) *badger.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package search
+
+import (
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+)
+
+// Query contains the filters of a transaction search. Filters that are not set
+// are ignored, and transactions have to match all of the filters that are set.
+// The offset is the number of matching transactions to skip, and the limit is
+// the maximum number of matching transactions to return.
+type Query struct {
+	AccountID     *identifier.Account
+	TransactionID *identifier.Transaction
+	Type          string
+	Success       *bool
+	MaxBlock      *uint64
+	Offset        uint
+	Limit         uint
+}
@@ -0,0 +1,286 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package spork
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Index is a DPS index that routes each request to one of multiple DPS indexes,
+// each of which covers the blocks of one spork. Requests for a height are sent
+// to the spork covering the height, while requests for an identifier are sent
+// to each spork, from the most recent one to the oldest one, until one of them
+// has it; any other failure than the data not being found is returned at once.
+// Callers that know the height of the data they look up by identifier
+// can get the index of the spork covering that height from Spork instead.
+type Index struct {
+	sporks []dps.Reader
+	firsts []uint64
+}
+
+// NewIndex creates a new index that routes requests over the given indexes,
+// which need to be ordered by ascending heights. The first height of each
+// index is retrieved once, and delimits the height range it covers.
+func NewIndex(sporks ...dps.Reader) (*Index, error) {
+
+	if len(sporks) == 0 {
+		return nil, fmt.Errorf("at least one spork index is required")
+	}
+
+	firsts := make([]uint64, 0, len(sporks))
+	for number, spork := range sporks {
+		first, err := spork.First()
+		if err != nil {
+			return nil, fmt.Errorf("could not get first height of spork %d: %w", number, err)
+		}
+		if number > 0 && first <= firsts[number-1] {
+			return nil, fmt.Errorf("spork %d does not start after spork %d (first: %d, previous: %d)", number, number-1, first, firsts[number-1])
+		}
+		firsts = append(firsts, first)
+	}
+
+	i := Index{
+		sporks: sporks,
+		firsts: firsts,
+	}
+
+	return &i, nil
+}
+
+// First returns the first height of the oldest spork.
+func (i *Index) First() (uint64, error) {
+	return i.firsts[0], nil
+}
+
+// Last returns the last indexed height of the most recent spork.
+func (i *Index) Last() (uint64, error) {
+	return i.sporks[len(i.sporks)-1].Last()
+}
+
+// HeightForBlock returns the height of the given block, from the spork that indexed it.
+func (i *Index) HeightForBlock(blockID flow.Identifier) (uint64, error) {
+	var height uint64
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		height, err = spork.HeightForBlock(blockID)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not find block height in any spork: %w", err)
+	}
+	return height, nil
+}
+
+// HeightForTransaction returns the height of the given transaction, from the spork that indexed it.
+func (i *Index) HeightForTransaction(txID flow.Identifier) (uint64, error) {
+	var height uint64
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		height, err = spork.HeightForTransaction(txID)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not find transaction height in any spork: %w", err)
+	}
+	return height, nil
+}
+
+// Commit returns the state commitment at the given height.
+func (i *Index) Commit(height uint64) (flow.StateCommitment, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return flow.DummyStateCommitment, err
+	}
+	return spork.Commit(height)
+}
+
+// Header returns the block header at the given height.
+func (i *Index) Header(height uint64) (*flow.Header, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return nil, err
+	}
+	return spork.Header(height)
+}
+
+// Events returns the events of the given types at the given height.
+func (i *Index) Events(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return nil, err
+	}
+	return spork.Events(height, types...)
+}
+
+// Values returns the register values for the given paths at the given height.
+func (i *Index) Values(height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return nil, err
+	}
+	return spork.Values(height, paths)
+}
+
+// Collection returns the given collection, from the spork that indexed it.
+func (i *Index) Collection(collID flow.Identifier) (*flow.LightCollection, error) {
+	var collection *flow.LightCollection
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		collection, err = spork.Collection(collID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not find collection in any spork: %w", err)
+	}
+	return collection, nil
+}
+
+// Guarantee returns the guarantee for the given collection, from the spork that indexed it.
+func (i *Index) Guarantee(collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+	var guarantee *flow.CollectionGuarantee
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		guarantee, err = spork.Guarantee(collID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not find guarantee in any spork: %w", err)
+	}
+	return guarantee, nil
+}
+
+// Transaction returns the given transaction, from the spork that indexed it.
+func (i *Index) Transaction(txID flow.Identifier) (*flow.TransactionBody, error) {
+	var tx *flow.TransactionBody
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		tx, err = spork.Transaction(txID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not find transaction in any spork: %w", err)
+	}
+	return tx, nil
+}
+
+// Seal returns the given seal, from the spork that indexed it.
+func (i *Index) Seal(sealID flow.Identifier) (*flow.Seal, error) {
+	var seal *flow.Seal
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		seal, err = spork.Seal(sealID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not find seal in any spork: %w", err)
+	}
+	return seal, nil
+}
+
+// Result returns the result of the given transaction, from the spork that indexed it.
+func (i *Index) Result(txID flow.Identifier) (*flow.TransactionResult, error) {
+	var result *flow.TransactionResult
+	err := i.search(func(spork dps.Reader) error {
+		var err error
+		result, err = spork.Result(txID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not find transaction result in any spork: %w", err)
+	}
+	return result, nil
+}
+
+// CollectionsByHeight returns the identifiers of the collections at the given height.
+func (i *Index) CollectionsByHeight(height uint64) ([]flow.Identifier, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return nil, err
+	}
+	return spork.CollectionsByHeight(height)
+}
+
+// TransactionsByHeight returns the identifiers of the transactions at the given height.
+func (i *Index) TransactionsByHeight(height uint64) ([]flow.Identifier, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return nil, err
+	}
+	return spork.TransactionsByHeight(height)
+}
+
+// SealsByHeight returns the identifiers of the seals at the given height.
+func (i *Index) SealsByHeight(height uint64) ([]flow.Identifier, error) {
+	spork, err := i.Spork(height)
+	if err != nil {
+		return nil, err
+	}
+	return spork.SealsByHeight(height)
+}
+
+// Spork returns the index of the spork that covers the given height, which is
+// the most recent spork that starts at or below it.
+func (i *Index) Spork(height uint64) (dps.Reader, error) {
+	for number := len(i.sporks) - 1; number >= 0; number-- {
+		if height >= i.firsts[number] {
+			return i.sporks[number], nil
+		}
+	}
+	return nil, fmt.Errorf("height below first spork (height: %d, first: %d)", height, i.firsts[0])
+}
+
+// search executes the given lookup on each spork, from the most recent one to
+// the oldest one, until it succeeds. A spork that does not have the data moves
+// the search on to the previous spork, while any other failure is returned
+// right away, so that it is not mistaken for the data not being found. If none
+// of the sporks have the data, the error of the oldest spork is returned.
+func (i *Index) search(lookup func(spork dps.Reader) error) error {
+	var err error
+	for number := len(i.sporks) - 1; number >= 0; number-- {
+		err = lookup(i.sporks[number])
+		if err == nil {
+			return nil
+		}
+		if !notFound(err) {
+			return fmt.Errorf("could not look up spork %d: %w", number, err)
+		}
+	}
+	return err
+}
+
+// notFound returns whether the given error means that a spork does not have the
+// requested data. Local indexes report it with the key not found error of the
+// database, while the DPS API only forwards the message of the error of its index.
+func notFound(err error) bool {
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return true
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) && grpcErr.GRPCStatus().Code() == codes.NotFound {
+		return true
+	}
+	return strings.Contains(err.Error(), badger.ErrKeyNotFound.Error())
+}
@@ -0,0 +1,407 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package spork_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/spork"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestNewIndex(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index, err := spork.NewIndex(sporkReader(t, 0, 99), sporkReader(t, 100, 199))
+
+		require.NoError(t, err)
+		assert.NotNil(t, index)
+	})
+
+	t.Run("handles missing sporks", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := spork.NewIndex()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		failing := mocks.BaselineReader(t)
+		failing.FirstFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		_, err := spork.NewIndex(sporkReader(t, 0, 99), failing)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles unordered sporks", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := spork.NewIndex(sporkReader(t, 100, 199), sporkReader(t, 0, 99))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles overlapping sporks", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := spork.NewIndex(sporkReader(t, 0, 99), sporkReader(t, 0, 199))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_FirstLast(t *testing.T) {
+
+	index, err := spork.NewIndex(sporkReader(t, 10, 99), sporkReader(t, 100, 199), sporkReader(t, 200, 299))
+	require.NoError(t, err)
+
+	first, err := index.First()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), first)
+
+	last, err := index.Last()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(299), last)
+}
+
+func TestIndex_Height(t *testing.T) {
+
+	index, err := spork.NewIndex(sporkReader(t, 10, 99), sporkReader(t, 100, 199), sporkReader(t, 200, 299))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		height uint64
+	}{
+		{name: "first height of oldest spork", height: 10},
+		{name: "last height of oldest spork", height: 99},
+		{name: "first height of middle spork", height: 100},
+		{name: "height within middle spork", height: 150},
+		{name: "height within most recent spork", height: 250},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Each spork reader asserts that it only receives heights within
+			// its own range, and returns values derived from the height.
+			header, err := index.Header(test.height)
+			require.NoError(t, err)
+			assert.Equal(t, test.height, header.Height)
+
+			commit, err := index.Commit(test.height)
+			require.NoError(t, err)
+			assert.Equal(t, flow.StateCommitment(commitFor(test.height)), commit)
+
+			events, err := index.Events(test.height)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, uint32(test.height), events[0].EventIndex)
+
+			values, err := index.Values(test.height, nil)
+			require.NoError(t, err)
+			assert.Equal(t, []ledger.Value{valueFor(test.height)}, values)
+
+			collIDs, err := index.CollectionsByHeight(test.height)
+			require.NoError(t, err)
+			assert.Equal(t, []flow.Identifier{idFor(test.height)}, collIDs)
+
+			txIDs, err := index.TransactionsByHeight(test.height)
+			require.NoError(t, err)
+			assert.Equal(t, []flow.Identifier{idFor(test.height)}, txIDs)
+
+			sealIDs, err := index.SealsByHeight(test.height)
+			require.NoError(t, err)
+			assert.Equal(t, []flow.Identifier{idFor(test.height)}, sealIDs)
+		})
+	}
+
+	t.Run("handles height below first spork", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := index.Header(9)
+		assert.Error(t, err)
+
+		_, err = index.Commit(9)
+		assert.Error(t, err)
+
+		_, err = index.Events(9)
+		assert.Error(t, err)
+
+		_, err = index.Values(9, nil)
+		assert.Error(t, err)
+
+		_, err = index.CollectionsByHeight(9)
+		assert.Error(t, err)
+
+		_, err = index.TransactionsByHeight(9)
+		assert.Error(t, err)
+
+		_, err = index.SealsByHeight(9)
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Identifier(t *testing.T) {
+
+	// Identifiers are found by the spork whose first height they encode.
+	index, err := spork.NewIndex(sporkReader(t, 10, 99), sporkReader(t, 100, 199), sporkReader(t, 200, 299))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		height uint64
+	}{
+		{name: "identifier in oldest spork", height: 10},
+		{name: "identifier in middle spork", height: 100},
+		{name: "identifier in most recent spork", height: 200},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			id := idFor(test.height)
+
+			height, err := index.HeightForBlock(id)
+			require.NoError(t, err)
+			assert.Equal(t, test.height, height)
+
+			height, err = index.HeightForTransaction(id)
+			require.NoError(t, err)
+			assert.Equal(t, test.height, height)
+
+			collection, err := index.Collection(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, collection.Transactions[0])
+
+			guarantee, err := index.Guarantee(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, guarantee.CollectionID)
+
+			tx, err := index.Transaction(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, tx.ReferenceBlockID)
+
+			seal, err := index.Seal(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, seal.BlockID)
+
+			result, err := index.Result(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, result.TransactionID)
+		})
+	}
+
+	t.Run("routes identifiers by height", func(t *testing.T) {
+		t.Parallel()
+
+		// The identifier of the oldest spork is only found by the index of the
+		// spork covering its height.
+		id := idFor(10)
+
+		reader, err := index.Spork(50)
+		require.NoError(t, err)
+		_, err = reader.Transaction(id)
+		assert.NoError(t, err)
+
+		reader, err = index.Spork(150)
+		require.NoError(t, err)
+		_, err = reader.Transaction(id)
+		assert.Error(t, err)
+
+		_, err = index.Spork(5)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles failing spork", func(t *testing.T) {
+		t.Parallel()
+
+		// The middle spork fails with something else than the data not being
+		// found, so the search stops there instead of reaching the oldest spork.
+		middle := sporkReader(t, 100, 199)
+		middle.TransactionFunc = func(flow.Identifier) (*flow.TransactionBody, error) {
+			return nil, mocks.GenericError
+		}
+		oldest := sporkReader(t, 10, 99)
+		oldest.TransactionFunc = func(flow.Identifier) (*flow.TransactionBody, error) {
+			t.Fatal("oldest spork should not be searched after a failure")
+			return nil, nil
+		}
+
+		index, err := spork.NewIndex(oldest, middle, sporkReader(t, 200, 299))
+		require.NoError(t, err)
+
+		_, err = index.Transaction(idFor(10))
+		assert.ErrorIs(t, err, mocks.GenericError)
+
+		tx, err := index.Transaction(idFor(200))
+		require.NoError(t, err)
+		assert.Equal(t, idFor(200), tx.ReferenceBlockID)
+	})
+
+	t.Run("searches past identifier not found through the DPS API", func(t *testing.T) {
+		t.Parallel()
+
+		// The DPS API only forwards the message of the error of its index.
+		recent := sporkReader(t, 100, 199)
+		recent.TransactionFunc = func(flow.Identifier) (*flow.TransactionBody, error) {
+			return nil, errors.New("could not get transaction: rpc error: code = Unknown desc = could not retrieve transaction: Key not found")
+		}
+
+		index, err := spork.NewIndex(sporkReader(t, 10, 99), recent)
+		require.NoError(t, err)
+
+		tx, err := index.Transaction(idFor(10))
+		require.NoError(t, err)
+		assert.Equal(t, idFor(10), tx.ReferenceBlockID)
+	})
+
+	t.Run("handles unknown identifier", func(t *testing.T) {
+		t.Parallel()
+
+		id := idFor(42)
+
+		_, err := index.HeightForBlock(id)
+		assert.Error(t, err)
+
+		_, err = index.HeightForTransaction(id)
+		assert.Error(t, err)
+
+		_, err = index.Collection(id)
+		assert.Error(t, err)
+
+		_, err = index.Guarantee(id)
+		assert.Error(t, err)
+
+		_, err = index.Transaction(id)
+		assert.Error(t, err)
+
+		_, err = index.Seal(id)
+		assert.Error(t, err)
+
+		_, err = index.Result(id)
+		assert.Error(t, err)
+	})
+}
+
+// sporkReader returns a reader for a spork covering the given range of heights.
+// Its height lookups fail the test for heights outside of the range, and its
+// identifier lookups only succeed for the identifier derived from its first height,
+// and report any other identifier as not found.
+func sporkReader(t *testing.T, first uint64, last uint64) *mocks.Reader {
+	t.Helper()
+
+	check := func(height uint64) {
+		assert.GreaterOrEqual(t, height, first)
+		assert.LessOrEqual(t, height, last)
+	}
+	known := func(id flow.Identifier) error {
+		if id != idFor(first) {
+			return badger.ErrKeyNotFound
+		}
+		return nil
+	}
+
+	reader := mocks.BaselineReader(t)
+	reader.FirstFunc = func() (uint64, error) {
+		return first, nil
+	}
+	reader.LastFunc = func() (uint64, error) {
+		return last, nil
+	}
+	reader.HeaderFunc = func(height uint64) (*flow.Header, error) {
+		check(height)
+		return &flow.Header{Height: height}, nil
+	}
+	reader.CommitFunc = func(height uint64) (flow.StateCommitment, error) {
+		check(height)
+		return flow.StateCommitment(commitFor(height)), nil
+	}
+	reader.EventsFunc = func(height uint64, _ ...flow.EventType) ([]flow.Event, error) {
+		check(height)
+		return []flow.Event{{EventIndex: uint32(height)}}, nil
+	}
+	reader.ValuesFunc = func(height uint64, _ []ledger.Path) ([]ledger.Value, error) {
+		check(height)
+		return []ledger.Value{valueFor(height)}, nil
+	}
+	reader.CollectionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+		check(height)
+		return []flow.Identifier{idFor(height)}, nil
+	}
+	reader.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+		check(height)
+		return []flow.Identifier{idFor(height)}, nil
+	}
+	reader.SealsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+		check(height)
+		return []flow.Identifier{idFor(height)}, nil
+	}
+	reader.HeightForBlockFunc = func(blockID flow.Identifier) (uint64, error) {
+		return first, known(blockID)
+	}
+	reader.HeightForTransactionFunc = func(txID flow.Identifier) (uint64, error) {
+		return first, known(txID)
+	}
+	reader.CollectionFunc = func(collID flow.Identifier) (*flow.LightCollection, error) {
+		return &flow.LightCollection{Transactions: []flow.Identifier{collID}}, known(collID)
+	}
+	reader.GuaranteeFunc = func(collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+		return &flow.CollectionGuarantee{CollectionID: collID}, known(collID)
+	}
+	reader.TransactionFunc = func(txID flow.Identifier) (*flow.TransactionBody, error) {
+		return &flow.TransactionBody{ReferenceBlockID: txID}, known(txID)
+	}
+	reader.SealFunc = func(sealID flow.Identifier) (*flow.Seal, error) {
+		return &flow.Seal{BlockID: sealID}, known(sealID)
+	}
+	reader.ResultFunc = func(txID flow.Identifier) (*flow.TransactionResult, error) {
+		return &flow.TransactionResult{TransactionID: txID}, known(txID)
+	}
+
+	return reader
+}
+
+func idFor(height uint64) flow.Identifier {
+	return flow.Identifier{byte(height >> 8), byte(height)}
+}
+
+func commitFor(height uint64) flow.Identifier {
+	return flow.Identifier{0xff, byte(height >> 8), byte(height)}
+}
+
+func valueFor(height uint64) ledger.Value {
+	return ledger.Value{byte(height >> 8), byte(height)}
+}
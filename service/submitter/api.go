@@ -22,7 +22,9 @@ import (
 	sdk "github.com/onflow/flow-go-sdk"
 )
 
-// API represents something that can be used to submit transactions.
+// API represents something that can be used to submit transactions and to
+// look up their status.
 type API interface {
 	SendTransaction(ctx context.Context, tx sdk.Transaction, opts ...grpc.CallOption) error
+	GetTransactionResult(ctx context.Context, txID sdk.Identifier, opts ...grpc.CallOption) (*sdk.TransactionResult, error)
 }
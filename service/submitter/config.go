@@ -0,0 +1,50 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package submitter
+
+import (
+	"time"
+)
+
+// Config is the configuration for the Rosetta submitter component.
+type Config struct {
+	Workers uint
+	Timeout time.Duration
+	TTL     time.Duration
+}
+
+// WithWorkers sets the maximum number of transaction statuses that are looked
+// up concurrently in a Config.
+func WithWorkers(workers uint) func(*Config) {
+	return func(c *Config) {
+		c.Workers = workers
+	}
+}
+
+// WithTimeout sets the maximum duration of a request to the Access API in a
+// Config.
+func WithTimeout(timeout time.Duration) func(*Config) {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+// WithTTL sets the duration for which submitted transactions are tracked in a
+// Config, after which they are considered expired.
+func WithTTL(ttl time.Duration) func(*Config) {
+	return func(c *Config) {
+		c.TTL = ttl
+	}
+}
@@ -17,30 +17,192 @@ package submitter
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	sdk "github.com/onflow/flow-go-sdk"
 )
 
-// Submitter submits transactions for execution.
+// Submitter submits transactions for execution and keeps track of the
+// submitted transactions until they are included in a block or expire.
 type Submitter struct {
+	cfg Config
+
 	// api is typically a Flow SDK client.
 	api API
+
+	mutex     sync.Mutex
+	submitted map[sdk.Identifier]submission
+}
+
+// submission is a submitted transaction along with the time it was submitted.
+type submission struct {
+	tx   *sdk.Transaction
+	time time.Time
 }
 
 // New creates a new Submitter that uses the given API.
-func New(api API) *Submitter {
+func New(api API, options ...func(*Config)) *Submitter {
+
+	// Transactions expire 600 blocks after their reference block, which takes
+	// about ten minutes, so by default they are tracked for a bit longer.
+	cfg := Config{
+		Workers: 8,
+		Timeout: 5 * time.Second,
+		TTL:     15 * time.Minute,
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
 	s := Submitter{
-		api: api,
+		cfg:       cfg,
+		api:       api,
+		submitted: make(map[sdk.Identifier]submission),
 	}
+
 	return &s
 }
 
 // Transaction submits the given transaction for execution.
 func (s *Submitter) Transaction(tx *sdk.Transaction) error {
-	err := s.api.SendTransaction(context.Background(), *tx)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	err := s.api.SendTransaction(ctx, *tx)
 	if err != nil {
 		return fmt.Errorf("could not submit transaction: %w", err)
 	}
 
+	s.mutex.Lock()
+	s.submitted[tx.ID()] = submission{tx: tx, time: time.Now()}
+	s.mutex.Unlock()
+
 	return nil
 }
+
+// Pending returns the submitted transactions that the API still reports as
+// pending, sorted by identifier. Transactions that have been included in a
+// block, that have expired or that were submitted longer ago than the TTL are
+// no longer tracked. The statuses are looked up concurrently by a bounded
+// number of workers, and transactions for which the lookup fails are left out
+// of the result, while they remain tracked.
+func (s *Submitter) Pending() ([]*sdk.Transaction, error) {
+
+	txs := s.tracked()
+
+	sort.Slice(txs, func(i int, j int) bool {
+		return txs[i].ID().Hex() < txs[j].ID().Hex()
+	})
+
+	workers := int(s.cfg.Workers)
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Each worker looks up the status of the transactions for the positions it
+	// receives, and marks the ones that are still pending.
+	pending := make([]bool, len(txs))
+	positions := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for position := range positions {
+				ok, err := s.status(txs[position].ID())
+				pending[position] = ok && err == nil
+			}
+		}()
+	}
+
+	for position := range txs {
+		positions <- position
+	}
+	close(positions)
+	wg.Wait()
+
+	result := make([]*sdk.Transaction, 0, len(txs))
+	for position, tx := range txs {
+		if pending[position] {
+			result = append(result, tx)
+		}
+	}
+
+	return result, nil
+}
+
+// Lookup returns the submitted transaction with the given ID, if it is still
+// pending. Only the status of that transaction is looked up.
+func (s *Submitter) Lookup(txID sdk.Identifier) (*sdk.Transaction, bool, error) {
+
+	s.mutex.Lock()
+	submission, ok := s.submitted[txID]
+	if ok && time.Since(submission.time) > s.cfg.TTL {
+		delete(s.submitted, txID)
+		ok = false
+	}
+	s.mutex.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	ok, err := s.status(txID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	return submission.tx, true, nil
+}
+
+// tracked returns the tracked transactions, after no longer tracking the ones
+// that were submitted longer ago than the TTL.
+func (s *Submitter) tracked() []*sdk.Transaction {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	txs := make([]*sdk.Transaction, 0, len(s.submitted))
+	for txID, submission := range s.submitted {
+		if now.Sub(submission.time) > s.cfg.TTL {
+			delete(s.submitted, txID)
+			continue
+		}
+		txs = append(txs, submission.tx)
+	}
+
+	return txs
+}
+
+// status looks up whether the transaction with the given ID is still pending.
+// If it is not, it is no longer tracked.
+func (s *Submitter) status(txID sdk.Identifier) (bool, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	result, err := s.api.GetTransactionResult(ctx, txID)
+	if err != nil {
+		return false, fmt.Errorf("could not get transaction result (%x): %w", txID, err)
+	}
+
+	switch result.Status {
+	case sdk.TransactionStatusUnknown, sdk.TransactionStatusPending:
+		return true, nil
+	default:
+		s.mutex.Lock()
+		delete(s.submitted, txID)
+		s.mutex.Unlock()
+		return false, nil
+	}
+}
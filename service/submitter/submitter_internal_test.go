@@ -0,0 +1,39 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package submitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestNew(t *testing.T) {
+	api := mocks.BaselineAccessAPI(t)
+
+	s := New(api, WithWorkers(42), WithTimeout(time.Minute), WithTTL(time.Hour))
+
+	require.NotNil(t, s)
+	assert.Equal(t, api, s.api)
+	assert.Equal(t, uint(42), s.cfg.Workers)
+	assert.Equal(t, time.Minute, s.cfg.Timeout)
+	assert.Equal(t, time.Hour, s.cfg.TTL)
+	assert.NotNil(t, s.submitted)
+	assert.Empty(t, s.submitted)
+}
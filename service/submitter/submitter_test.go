@@ -0,0 +1,370 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package submitter_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	sdk "github.com/onflow/flow-go-sdk"
+
+	"github.com/optakt/flow-dps-rosetta/service/submitter"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestSubmitter_Transaction(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		tx := transaction(1)
+
+		api := mocks.BaselineAccessAPI(t)
+		api.SendTransactionFunc = func(_ context.Context, sent sdk.Transaction, _ ...grpc.CallOption) error {
+			assert.Equal(t, tx.ID(), sent.ID())
+			return nil
+		}
+
+		s := submitter.New(api)
+
+		err := s.Transaction(tx)
+		require.NoError(t, err)
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Equal(t, []*sdk.Transaction{tx}, pending)
+	})
+
+	t.Run("handles submission failure", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.SendTransactionFunc = func(context.Context, sdk.Transaction, ...grpc.CallOption) error {
+			return mocks.GenericError
+		}
+
+		s := submitter.New(api)
+
+		err := s.Transaction(transaction(1))
+		assert.Error(t, err)
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
+
+func TestSubmitter_Pending(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		txs := []*sdk.Transaction{
+			transaction(1),
+			transaction(2),
+			transaction(3),
+			transaction(4),
+			transaction(5),
+			transaction(6),
+		}
+		statuses := map[sdk.Identifier]sdk.TransactionStatus{
+			txs[0].ID(): sdk.TransactionStatusUnknown,
+			txs[1].ID(): sdk.TransactionStatusPending,
+			txs[2].ID(): sdk.TransactionStatusFinalized,
+			txs[3].ID(): sdk.TransactionStatusExecuted,
+			txs[4].ID(): sdk.TransactionStatusSealed,
+			txs[5].ID(): sdk.TransactionStatusExpired,
+		}
+
+		var mutex sync.Mutex
+		var calls int
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(_ context.Context, txID sdk.Identifier, _ ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			mutex.Lock()
+			calls++
+			mutex.Unlock()
+			status, ok := statuses[txID]
+			require.True(t, ok)
+			result := sdk.TransactionResult{
+				Status: status,
+			}
+			return &result, nil
+		}
+
+		s := submitter.New(api)
+		for _, tx := range txs {
+			err := s.Transaction(tx)
+			require.NoError(t, err)
+		}
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, txs[:2], pending)
+		assert.Equal(t, len(txs), calls)
+
+		// Transactions that are no longer pending are not looked up again.
+		pending, err = s.Pending()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, txs[:2], pending)
+		assert.Equal(t, len(txs)+2, calls)
+	})
+
+	t.Run("sorts transactions by identifier", func(t *testing.T) {
+		t.Parallel()
+
+		s := submitter.New(mocks.BaselineAccessAPI(t))
+		for seq := uint64(1); seq <= 10; seq++ {
+			err := s.Transaction(transaction(seq))
+			require.NoError(t, err)
+		}
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		require.Len(t, pending, 10)
+		for i := 1; i < len(pending); i++ {
+			assert.Less(t, pending[i-1].ID().Hex(), pending[i].ID().Hex())
+		}
+	})
+
+	t.Run("handles no submitted transactions", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			t.Fatal("unexpected transaction result lookup")
+			return nil, nil
+		}
+
+		s := submitter.New(api)
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("bounds concurrent lookups", func(t *testing.T) {
+		t.Parallel()
+
+		var mutex sync.Mutex
+		var active, max int
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			mutex.Lock()
+			active++
+			if active > max {
+				max = active
+			}
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mutex.Lock()
+			active--
+			mutex.Unlock()
+
+			return &sdk.TransactionResult{Status: sdk.TransactionStatusPending}, nil
+		}
+
+		s := submitter.New(api, submitter.WithWorkers(2))
+		for seq := uint64(1); seq <= 10; seq++ {
+			err := s.Transaction(transaction(seq))
+			require.NoError(t, err)
+		}
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Len(t, pending, 10)
+		assert.LessOrEqual(t, max, 2)
+	})
+
+	t.Run("stops tracking transactions after TTL", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			t.Fatal("unexpected transaction result lookup")
+			return nil, nil
+		}
+
+		s := submitter.New(api, submitter.WithTTL(time.Millisecond))
+		err := s.Transaction(transaction(1))
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("skips transactions with failed lookup", func(t *testing.T) {
+		t.Parallel()
+
+		txs := []*sdk.Transaction{
+			transaction(1),
+			transaction(2),
+		}
+
+		var mutex sync.Mutex
+		fail := true
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(_ context.Context, txID sdk.Identifier, _ ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if fail && txID == txs[0].ID() {
+				return nil, mocks.GenericError
+			}
+			return &sdk.TransactionResult{Status: sdk.TransactionStatusPending}, nil
+		}
+
+		s := submitter.New(api)
+		for _, tx := range txs {
+			err := s.Transaction(tx)
+			require.NoError(t, err)
+		}
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Equal(t, []*sdk.Transaction{txs[1]}, pending)
+
+		// The transaction is still tracked, so it is listed again once its
+		// status can be looked up.
+		mutex.Lock()
+		fail = false
+		mutex.Unlock()
+
+		pending, err = s.Pending()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, txs, pending)
+	})
+
+	t.Run("skips transactions with timed out lookup", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(ctx context.Context, _ sdk.Identifier, _ ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		s := submitter.New(api, submitter.WithTimeout(time.Millisecond))
+		err := s.Transaction(transaction(1))
+		require.NoError(t, err)
+
+		pending, err := s.Pending()
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
+
+func TestSubmitter_Lookup(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		tx := transaction(1)
+		other := transaction(2)
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(_ context.Context, txID sdk.Identifier, _ ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			assert.Equal(t, tx.ID(), txID)
+			return &sdk.TransactionResult{Status: sdk.TransactionStatusPending}, nil
+		}
+
+		s := submitter.New(api)
+		require.NoError(t, s.Transaction(tx))
+		require.NoError(t, s.Transaction(other))
+
+		got, ok, err := s.Lookup(tx.ID())
+
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, tx, got)
+	})
+
+	t.Run("handles transaction that was not submitted", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			t.Fatal("unexpected transaction result lookup")
+			return nil, nil
+		}
+
+		s := submitter.New(api)
+
+		_, ok, err := s.Lookup(transaction(1).ID())
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles transaction that is no longer pending", func(t *testing.T) {
+		t.Parallel()
+
+		tx := transaction(1)
+
+		var calls int
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			calls++
+			return &sdk.TransactionResult{Status: sdk.TransactionStatusSealed}, nil
+		}
+
+		s := submitter.New(api)
+		require.NoError(t, s.Transaction(tx))
+
+		_, ok, err := s.Lookup(tx.ID())
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		// The transaction is no longer tracked, so it is not looked up again.
+		_, ok, err = s.Lookup(tx.ID())
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("handles transaction result failure", func(t *testing.T) {
+		t.Parallel()
+
+		tx := transaction(1)
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetTransactionResultFunc = func(context.Context, sdk.Identifier, ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := submitter.New(api)
+		require.NoError(t, s.Transaction(tx))
+
+		_, _, err := s.Lookup(tx.ID())
+
+		assert.Error(t, err)
+	})
+}
+
+func transaction(sequence uint64) *sdk.Transaction {
+	tx := sdk.NewTransaction().
+		SetScript(mocks.GenericBytes).
+		SetProposalKey(sdk.HexToAddress("f8d6e0586b0a20c7"), 0, sequence)
+	return tx
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker
+
+import (
+	"time"
+)
+
+// Config is the configuration for the Rosetta tracker component.
+type Config struct {
+	Tolerance uint64
+	Timeout   time.Duration
+}
+
+// WithTolerance sets the number of blocks the index can lag behind the latest
+// sealed block while still being considered synced in a Config.
+func WithTolerance(tolerance uint64) func(*Config) {
+	return func(c *Config) {
+		c.Tolerance = tolerance
+	}
+}
+
+// WithTimeout sets the maximum duration of a request for the latest sealed
+// block to the Access API in a Config.
+func WithTimeout(timeout time.Duration) func(*Config) {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
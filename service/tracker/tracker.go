@@ -0,0 +1,103 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/optakt/flow-dps-rosetta/service/object"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Stages of the synchronization of the index with the Flow network.
+const (
+	StageIndexing = "indexing"
+	StageSynced   = "synced"
+)
+
+// Tracker compares the progress of the DPS index with the latest sealed block
+// of the Flow network.
+type Tracker struct {
+	cfg Config
+
+	index dps.Reader
+	// api is typically a Flow SDK client.
+	api API
+}
+
+// New creates a new Tracker that compares the given index with the given API.
+func New(index dps.Reader, api API, options ...func(*Config)) *Tracker {
+
+	cfg := Config{
+		Tolerance: 10,
+		Timeout:   5 * time.Second,
+	}
+
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	t := Tracker{
+		cfg:   cfg,
+		index: index,
+		api:   api,
+	}
+
+	return &t
+}
+
+// Sync returns the synchronization status of the index, using the last indexed
+// height as the current index and the latest sealed height as the target index.
+// It fails if the Access API does not answer within the configured timeout.
+func (t *Tracker) Sync() (*object.SyncStatus, error) {
+
+	current, err := t.index.Last()
+	if err != nil {
+		return nil, fmt.Errorf("could not find last indexed block: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.cfg.Timeout)
+	defer cancel()
+
+	header, err := t.api.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get latest sealed block header: %w", err)
+	}
+
+	// The Access API can be behind the index, for example if it is load
+	// balanced over multiple nodes, so we never report a target below the
+	// current index.
+	target := header.Height
+	if target < current {
+		target = current
+	}
+
+	synced := target-current <= t.cfg.Tolerance
+	stage := StageIndexing
+	if synced {
+		stage = StageSynced
+	}
+
+	status := object.SyncStatus{
+		CurrentIndex: current,
+		TargetIndex:  target,
+		Stage:        stage,
+		Synced:       synced,
+	}
+
+	return &status, nil
+}
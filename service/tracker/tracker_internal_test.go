@@ -0,0 +1,38 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestNew(t *testing.T) {
+	index := mocks.BaselineReader(t)
+	api := mocks.BaselineAccessAPI(t)
+
+	tr := New(index, api, WithTolerance(42), WithTimeout(time.Minute))
+
+	require.NotNil(t, tr)
+	assert.Equal(t, index, tr.index)
+	assert.Equal(t, api, tr.api)
+	assert.Equal(t, uint64(42), tr.cfg.Tolerance)
+	assert.Equal(t, time.Minute, tr.cfg.Timeout)
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	sdk "github.com/onflow/flow-go-sdk"
+
+	"github.com/optakt/flow-dps-rosetta/service/tracker"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+)
+
+func TestTracker_Sync(t *testing.T) {
+
+	tests := []struct {
+		name string
+
+		current   uint64
+		latest    uint64
+		tolerance uint64
+
+		wantTarget uint64
+		wantStage  string
+		wantSynced bool
+	}{
+		{
+			name:       "index at latest sealed block",
+			current:    100,
+			latest:     100,
+			tolerance:  10,
+			wantTarget: 100,
+			wantStage:  tracker.StageSynced,
+			wantSynced: true,
+		},
+		{
+			name:       "index lagging within tolerance",
+			current:    90,
+			latest:     100,
+			tolerance:  10,
+			wantTarget: 100,
+			wantStage:  tracker.StageSynced,
+			wantSynced: true,
+		},
+		{
+			name:       "index lagging beyond tolerance",
+			current:    89,
+			latest:     100,
+			tolerance:  10,
+			wantTarget: 100,
+			wantStage:  tracker.StageIndexing,
+			wantSynced: false,
+		},
+		{
+			name:       "index lagging with zero tolerance",
+			current:    99,
+			latest:     100,
+			tolerance:  0,
+			wantTarget: 100,
+			wantStage:  tracker.StageIndexing,
+			wantSynced: false,
+		},
+		{
+			name:       "index ahead of access API",
+			current:    105,
+			latest:     100,
+			tolerance:  0,
+			wantTarget: 105,
+			wantStage:  tracker.StageSynced,
+			wantSynced: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.LastFunc = func() (uint64, error) {
+				return test.current, nil
+			}
+
+			api := mocks.BaselineAccessAPI(t)
+			api.GetLatestBlockHeaderFunc = func(_ context.Context, isSealed bool, _ ...grpc.CallOption) (*sdk.BlockHeader, error) {
+				assert.True(t, isSealed)
+
+				return &sdk.BlockHeader{Height: test.latest}, nil
+			}
+
+			track := tracker.New(index, api, tracker.WithTolerance(test.tolerance))
+
+			status, err := track.Sync()
+
+			require.NoError(t, err)
+			require.NotNil(t, status)
+			assert.Equal(t, test.current, status.CurrentIndex)
+			assert.Equal(t, test.wantTarget, status.TargetIndex)
+			assert.Equal(t, test.wantStage, status.Stage)
+			assert.Equal(t, test.wantSynced, status.Synced)
+		})
+	}
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.LastFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		track := tracker.New(index, mocks.BaselineAccessAPI(t))
+
+		_, err := track.Sync()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles access API failure", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetLatestBlockHeaderFunc = func(context.Context, bool, ...grpc.CallOption) (*sdk.BlockHeader, error) {
+			return nil, mocks.GenericError
+		}
+
+		track := tracker.New(mocks.BaselineReader(t), api)
+
+		_, err := track.Sync()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles access API timeout", func(t *testing.T) {
+		t.Parallel()
+
+		api := mocks.BaselineAccessAPI(t)
+		api.GetLatestBlockHeaderFunc = func(ctx context.Context, _ bool, _ ...grpc.CallOption) (*sdk.BlockHeader, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		track := tracker.New(mocks.BaselineReader(t), api, tracker.WithTimeout(time.Millisecond))
+
+		_, err := track.Sync()
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -37,12 +37,17 @@ const (
 	amountUnparseable   = "could not parse transaction amount"
 	amountInvalid       = "invalid amount"
 	receiverUnparseable = "could not parse transaction receiver address"
+	receiverInvalid     = "invalid receiver address"
 
 	// Operations/intent errors.
 	opsInvalid          = "invalid number of operations"
 	opsAmountsMismatch  = "transfer amounts do not match"
 	currenciesInvalid   = "invalid currencies found"
 	opAmountUnparseable = "could not parse amount"
+	opAmountMissing     = "missing amount"
 	opTypeInvalid       = "only transfer operations are supported"
 	keyInvalid          = "invalid account key"
+
+	// Mempool errors.
+	txNotPending = "transaction is not pending"
 )
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/onflow/cadence"
 	cjson "github.com/onflow/cadence/encoding/json"
 	sdk "github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/crypto"
@@ -238,9 +239,15 @@ func (p *TransactionParser) Operations() ([]object.Operation, error) {
 				failure.WithErr(err)),
 		}
 	}
-	addr := flow.HexToAddress(val.String())
+	addr, ok := val.(cadence.Address)
+	if !ok {
+		return nil, failure.InvalidReceiver{
+			Receiver:    string(args[1]),
+			Description: failure.NewDescription(receiverInvalid),
+		}
+	}
 	receiver := identifier.Account{
-		Address: addr.String(),
+		Address: flow.Address(addr).String(),
 	}
 	_, err = p.validate.Account(receiver)
 	if err != nil {
@@ -255,7 +262,7 @@ func (p *TransactionParser) Operations() ([]object.Operation, error) {
 		},
 		AccountID: sender,
 		Type:      dps.OperationTransfer,
-		Amount: object.Amount{
+		Amount: &object.Amount{
 			Value: "-" + amount,
 			Currency: identifier.Currency{
 				Symbol:   dps.FlowSymbol,
@@ -273,7 +280,7 @@ func (p *TransactionParser) Operations() ([]object.Operation, error) {
 		},
 		AccountID: receiver,
 		Type:      dps.OperationTransfer,
-		Amount: object.Amount{
+		Amount: &object.Amount{
 			Value: amount,
 			Currency: identifier.Currency{
 				Symbol:   dps.FlowSymbol,
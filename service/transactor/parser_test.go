@@ -587,6 +587,28 @@ func TestTransactionParser_Operations(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorAs(t, err, &failure.InvalidReceiver{})
 	})
+
+	t.Run("handles invalid address argument (not an address)", func(t *testing.T) {
+		t.Parallel()
+
+		tx := &sdk.Transaction{
+			Payer:       sender,
+			ProposalKey: sdk.ProposalKey{Address: sender},
+			Authorizers: []sdk.Address{sender},
+			Script:      mocks.GenericBytes,
+			Arguments:   [][]byte{amountData, amountData}, // Second argument is an amount.
+		}
+
+		p := transactor.BaselineTransactionParser(
+			t,
+			transactor.InjectTransaction(tx),
+		)
+
+		_, err := p.Operations()
+
+		require.Error(t, err)
+		assert.ErrorAs(t, err, &failure.InvalidReceiver{})
+	})
 }
 
 func generateKey() (*flow.AccountPrivateKey, error) {
@@ -18,7 +18,10 @@ import (
 	sdk "github.com/onflow/flow-go-sdk"
 )
 
-// Submitter represents something that can submit transactions.
+// Submitter represents something that can submit transactions, list the
+// submitted transactions that are still pending and look up one of them.
 type Submitter interface {
 	Transaction(tx *sdk.Transaction) error
+	Pending() ([]*sdk.Transaction, error)
+	Lookup(txID sdk.Identifier) (*sdk.Transaction, bool, error)
 }
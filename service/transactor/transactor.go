@@ -89,6 +89,13 @@ func (t *Transactor) DeriveIntent(operations []object.Operation) (*Intent, error
 	// Parse amounts.
 	amounts := make([]int64, requiredOperations)
 	for i, op := range operations {
+		if op.Amount == nil {
+			return nil, failure.InvalidIntent{
+				Description: failure.NewDescription(opAmountMissing,
+					failure.WithInt("index", i),
+				),
+			}
+		}
 		amount, err := strconv.ParseInt(op.Amount.Value, 10, 64)
 		if err != nil {
 			return nil, failure.InvalidIntent{
@@ -367,6 +374,67 @@ func (t *Transactor) SubmitTransaction(signed string) (identifier.Transaction, e
 	return rosettaTxID(signedTx.ID()), nil
 }
 
+// PendingTransactions returns the identifiers of the transactions submitted by
+// this transactor that have not been included in a block yet.
+func (t *Transactor) PendingTransactions() ([]identifier.Transaction, error) {
+
+	pending, err := t.submit.Pending()
+	if err != nil {
+		return nil, fmt.Errorf("could not get pending transactions: %w", err)
+	}
+
+	rosTxIDs := make([]identifier.Transaction, 0, len(pending))
+	for _, tx := range pending {
+		rosTxIDs = append(rosTxIDs, rosettaTxID(tx.ID()))
+	}
+
+	return rosTxIDs, nil
+}
+
+// PendingTransaction returns the given pending transaction, along with the
+// operations it is expected to perform once it is executed.
+func (t *Transactor) PendingTransaction(rosTxID identifier.Transaction) (*object.Transaction, error) {
+
+	txID, err := t.validate.Transaction(rosTxID)
+	if err != nil {
+		return nil, fmt.Errorf("could not validate transaction: %w", err)
+	}
+
+	tx, ok, err := t.submit.Lookup(sdk.Identifier(txID))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up pending transaction: %w", err)
+	}
+	if !ok {
+		return nil, failure.UnknownTransaction{
+			Hash:        rosTxID.Hash,
+			Description: failure.NewDescription(txNotPending),
+		}
+	}
+
+	p := TransactionParser{
+		tx:       tx,
+		validate: t.validate,
+		generate: t.generate,
+		invoke:   t.invoke,
+	}
+	operations, err := p.Operations()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse transaction operations: %w", err)
+	}
+
+	// The operation status is left empty, as the transaction has not been
+	// executed yet.
+	transaction := object.Transaction{
+		ID:         rosettaTxID(tx.ID()),
+		Operations: make([]*object.Operation, 0, len(operations)),
+	}
+	for i := range operations {
+		transaction.Operations = append(transaction.Operations, &operations[i])
+	}
+
+	return &transaction, nil
+}
+
 func (t *Transactor) encodeTransaction(tx *sdk.Transaction) (string, error) {
 
 	data, err := json.Marshal(tx)
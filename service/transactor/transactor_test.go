@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/cadence"
+	cjson "github.com/onflow/cadence/encoding/json"
 	sdk "github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go/model/flow"
 
@@ -96,6 +97,20 @@ func TestTransactor_DeriveIntent(t *testing.T) {
 		assert.ErrorAs(t, err, &failure.InvalidOperations{})
 	})
 
+	t.Run("handles operations with missing amounts", func(t *testing.T) {
+		t.Parallel()
+
+		tr := transactor.BaselineTransactor(t)
+
+		op := mocks.GenericOperations(2)
+		op[1].Amount = nil
+
+		_, err := tr.DeriveIntent(op)
+
+		assert.Error(t, err)
+		assert.ErrorAs(t, err, &failure.InvalidIntent{})
+	})
+
 	t.Run("handles operations with unparsable amounts", func(t *testing.T) {
 		t.Parallel()
 
@@ -770,3 +785,200 @@ func TestTransactor_SubmitTransaction(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestTransactor_PendingTransactions(t *testing.T) {
+	txs := []*sdk.Transaction{
+		{Script: mocks.GenericBytes},
+		{Script: mocks.GenericBytes, GasLimit: 42},
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		submitter := mocks.BaselineSubmitter(t)
+		submitter.PendingFunc = func() ([]*sdk.Transaction, error) {
+			return txs, nil
+		}
+
+		tr := transactor.BaselineTransactor(t, transactor.WithSubmitter(submitter))
+
+		got, err := tr.PendingTransactions()
+
+		require.NoError(t, err)
+		want := []identifier.Transaction{
+			{Hash: txs[0].ID().Hex()},
+			{Hash: txs[1].ID().Hex()},
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("nominal case without pending transactions", func(t *testing.T) {
+		t.Parallel()
+
+		tr := transactor.BaselineTransactor(t)
+
+		got, err := tr.PendingTransactions()
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("handles submitter failure", func(t *testing.T) {
+		t.Parallel()
+
+		submitter := mocks.BaselineSubmitter(t)
+		submitter.PendingFunc = func() ([]*sdk.Transaction, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr := transactor.BaselineTransactor(t, transactor.WithSubmitter(submitter))
+
+		_, err := tr.PendingTransactions()
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTransactor_PendingTransaction(t *testing.T) {
+	sender := sdk.HexToAddress(mocks.GenericAddress(0).Hex())
+	receiver := mocks.GenericAddress(1)
+
+	amountData, err := cjson.Encode(mocks.GenericAmount(0))
+	require.NoError(t, err)
+	addressData, err := cjson.Encode(cadence.BytesToAddress(receiver.Bytes()))
+	require.NoError(t, err)
+
+	tx := &sdk.Transaction{
+		Payer:       sender,
+		ProposalKey: sdk.ProposalKey{Address: sender},
+		Authorizers: []sdk.Address{sender},
+		Script:      mocks.GenericBytes,
+		Arguments:   [][]byte{amountData, addressData},
+	}
+	other := &sdk.Transaction{
+		Script: mocks.GenericBytes,
+	}
+	rosTxID := identifier.Transaction{Hash: tx.ID().Hex()}
+
+	submitter := mocks.BaselineSubmitter(t)
+	submitter.PendingFunc = func() ([]*sdk.Transaction, error) {
+		t.Fatal("unexpected listing of pending transactions")
+		return nil, nil
+	}
+	submitter.LookupFunc = func(txID sdk.Identifier) (*sdk.Transaction, bool, error) {
+		for _, pending := range []*sdk.Transaction{other, tx} {
+			if pending.ID() == txID {
+				return pending, true, nil
+			}
+		}
+		return nil, false, nil
+	}
+
+	validator := mocks.BaselineValidator(t)
+	validator.TransactionFunc = func(rosTxID identifier.Transaction) (flow.Identifier, error) {
+		return flow.HexStringToIdentifier(rosTxID.Hash)
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		tr := transactor.BaselineTransactor(
+			t,
+			transactor.WithSubmitter(submitter),
+			transactor.WithValidator(validator),
+		)
+
+		got, err := tr.PendingTransaction(rosTxID)
+
+		require.NoError(t, err)
+		assert.Equal(t, rosTxID, got.ID)
+		require.Len(t, got.Operations, 2)
+
+		send := got.Operations[0]
+		assert.Equal(t, sender.String(), send.AccountID.Address)
+		assert.Equal(t, dps.OperationTransfer, send.Type)
+		assert.Equal(t, "-"+fmt.Sprint(mocks.GenericAmount(0).ToGoValue()), send.Amount.Value)
+		assert.Empty(t, send.Status)
+
+		receive := got.Operations[1]
+		assert.Equal(t, receiver.String(), receive.AccountID.Address)
+		assert.Equal(t, dps.OperationTransfer, receive.Type)
+		assert.Equal(t, fmt.Sprint(mocks.GenericAmount(0).ToGoValue()), receive.Amount.Value)
+		assert.Empty(t, receive.Status)
+	})
+
+	t.Run("handles invalid transaction identifier", func(t *testing.T) {
+		t.Parallel()
+
+		validator := mocks.BaselineValidator(t)
+		validator.TransactionFunc = func(identifier.Transaction) (flow.Identifier, error) {
+			return flow.ZeroID, mocks.GenericError
+		}
+
+		tr := transactor.BaselineTransactor(
+			t,
+			transactor.WithSubmitter(submitter),
+			transactor.WithValidator(validator),
+		)
+
+		_, err := tr.PendingTransaction(rosTxID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles transaction that is not pending", func(t *testing.T) {
+		t.Parallel()
+
+		submitter := mocks.BaselineSubmitter(t)
+		submitter.LookupFunc = func(sdk.Identifier) (*sdk.Transaction, bool, error) {
+			return nil, false, nil
+		}
+
+		tr := transactor.BaselineTransactor(
+			t,
+			transactor.WithSubmitter(submitter),
+			transactor.WithValidator(validator),
+		)
+
+		_, err := tr.PendingTransaction(rosTxID)
+
+		require.Error(t, err)
+		assert.ErrorAs(t, err, &failure.UnknownTransaction{})
+	})
+
+	t.Run("handles submitter failure", func(t *testing.T) {
+		t.Parallel()
+
+		submitter := mocks.BaselineSubmitter(t)
+		submitter.LookupFunc = func(sdk.Identifier) (*sdk.Transaction, bool, error) {
+			return nil, false, mocks.GenericError
+		}
+
+		tr := transactor.BaselineTransactor(
+			t,
+			transactor.WithSubmitter(submitter),
+			transactor.WithValidator(validator),
+		)
+
+		_, err := tr.PendingTransaction(rosTxID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles unparsable transaction", func(t *testing.T) {
+		t.Parallel()
+
+		rosTxID := identifier.Transaction{Hash: other.ID().Hex()}
+
+		tr := transactor.BaselineTransactor(
+			t,
+			transactor.WithSubmitter(submitter),
+			transactor.WithValidator(validator),
+		)
+
+		_, err := tr.PendingTransaction(rosTxID)
+
+		require.Error(t, err)
+		assert.ErrorAs(t, err, &failure.InvalidAuthorizers{})
+	})
+}
@@ -20,9 +20,10 @@ import (
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 )
 
-// Validator represents something that can validate account and block identifiers as well as currencies.
+// Validator represents something that can validate account, block and transaction identifiers as well as currencies.
 type Validator interface {
 	Account(rosAccountID identifier.Account) (address flow.Address, err error)
 	Block(rosBlockID identifier.Block) (height uint64, blockID flow.Identifier, err error)
+	Transaction(rosTxID identifier.Transaction) (txID flow.Identifier, err error)
 	Currency(currency identifier.Currency) (symbol string, decimals uint, err error)
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps-rosetta/service/configuration"
 	"github.com/optakt/flow-dps-rosetta/service/failure"
 	"github.com/optakt/flow-dps-rosetta/service/identifier"
 )
@@ -50,5 +51,22 @@ func (v *Validator) Account(account identifier.Account) (flow.Address, error) {
 		}
 	}
 
+	// If a sub-account is given, it has to be one of the supported ones.
+	if account.SubAccount != nil {
+		switch account.SubAccount.Address {
+		case configuration.SubAccountLocked,
+			configuration.SubAccountStaked,
+			configuration.SubAccountDelegated,
+			configuration.SubAccountStorageReserved:
+		default:
+			return flow.EmptyAddress, failure.InvalidAccount{
+				Address: account.Address,
+				Description: failure.NewDescription(subAccountUnknown,
+					failure.WithString("sub_account", account.SubAccount.Address),
+				),
+			}
+		}
+	}
+
 	return address, nil
 }
@@ -16,6 +16,7 @@ package validator
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/onflow/flow-go/model/flow"
 
@@ -24,10 +25,28 @@ import (
 )
 
 // Block tries to extrapolate the block identifier to a full version
-// of itself. If both index and hash are zero values, it is assumed that the
-// latest block is referenced.
+// of itself. If only a timestamp is given, the last block at or before that
+// time is referenced. If index, hash and timestamp are all zero values, it
+// is assumed that the latest block is referenced.
 func (v *Validator) Block(rosBlockID identifier.Block) (uint64, flow.Identifier, error) {
 
+	// A timestamp can only be used on its own, as we would otherwise have to
+	// decide which of the fields takes precedence.
+	if rosBlockID.Timestamp != nil {
+		if rosBlockID.Index != nil || rosBlockID.Hash != "" {
+			return 0, flow.ZeroID, failure.InvalidBlock{
+				Description: failure.NewDescription(blockOverspecified,
+					failure.WithString("timestamp", strconv.FormatInt(*rosBlockID.Timestamp, 10)),
+				),
+			}
+		}
+		height, err := v.height(*rosBlockID.Timestamp)
+		if err != nil {
+			return 0, flow.ZeroID, fmt.Errorf("could not get height for timestamp: %w", err)
+		}
+		rosBlockID.Index = &height
+	}
+
 	// If both the index and the hash are missing, the block identifier is invalid, and
 	// the latest block ID is returned instead.
 	if rosBlockID.Index == nil && rosBlockID.Hash == "" {
@@ -111,6 +130,53 @@ func (v *Validator) Block(rosBlockID identifier.Block) (uint64, flow.Identifier,
 	return header.Height, header.ID(), nil
 }
 
+// height returns the height of the last block with a timestamp at or before the
+// given Unix timestamp in milliseconds. As block timestamps are increasing with
+// the height, it uses a binary search over the headers of the indexed blocks.
+func (v *Validator) height(timestamp int64) (uint64, error) {
+
+	first, err := v.index.First()
+	if err != nil {
+		return 0, fmt.Errorf("could not get first: %w", err)
+	}
+	last, err := v.index.Last()
+	if err != nil {
+		return 0, fmt.Errorf("could not get last: %w", err)
+	}
+
+	// If the timestamp is before the first block, no block matches it.
+	header, err := v.index.Header(first)
+	if err != nil {
+		return 0, fmt.Errorf("could not get header: %w", err)
+	}
+	if header.Timestamp.UnixNano()/1_000_000 > timestamp {
+		return 0, failure.InvalidBlock{
+			Description: failure.NewDescription(timestampTooLow,
+				failure.WithString("timestamp", strconv.FormatInt(timestamp, 10)),
+				failure.WithUint64("first_index", first),
+			),
+		}
+	}
+
+	// We keep the invariant that the block at the lower bound is at or before
+	// the timestamp, while all blocks above the upper bound are after it.
+	low, high := first, last
+	for low < high {
+		mid := low + (high-low+1)/2
+		header, err := v.index.Header(mid)
+		if err != nil {
+			return 0, fmt.Errorf("could not get header: %w", err)
+		}
+		if header.Timestamp.UnixNano()/1_000_000 <= timestamp {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	return low, nil
+}
+
 // CompleteBlockID verifies that both index and hash are populated in the block ID.
 func (v *Validator) CompleteBlockID(rosBlockID identifier.Block) error {
 	if rosBlockID.Index == nil || rosBlockID.Hash == "" {
@@ -30,11 +30,16 @@ const (
 	blockTooHigh  = "block index is above last indexed height"
 	blockMismatch = "block hash mismatches with authoritative hash for index"
 
+	// Block timestamp errors.
+	blockOverspecified = "block identifier can not have a timestamp together with index or hash"
+	timestampTooLow    = "block timestamp is before first indexed block"
+
 	// Account identifier errors.
 	addressEmpty         = "account identifier has empty address field"
 	addressInvalid       = "account address is not a valid hex-encoded string"
 	addressMisconfigured = "account address is not valid for configured chain"
 	addressLength        = "account identifier has invalid address field length"
+	subAccountUnknown    = "account identifier has unknown sub-account address"
 
 	// Currency identifier errors.
 	currenciesEmpty  = "currency identifier list is empty"
@@ -48,4 +53,8 @@ const (
 	txLength        = "transaction identifier has invalid hash field length"
 	txBodyEmpty     = "transaction text is empty"
 	signaturesEmpty = "signature list is empty"
+
+	// Search errors.
+	operatorUnsupported = "search operator is not supported"
+	subAccountSearch    = "search by sub-account is not supported"
 )
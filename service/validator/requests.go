@@ -42,6 +42,8 @@ const (
 	symbolField      = "symbol"
 	transactionField = "transaction"
 	signaturesField  = "signatures"
+	operatorField    = "operator"
+	subAccountField  = "sub_account"
 
 	blockchainFailTag = "blockchain"
 	networkFailTag    = "network"
@@ -66,10 +68,12 @@ func newRequestValidator(config Configuration) *validator.Validate {
 	// within the request. This way we can validate some standard types (strings)
 	// or complex ones (array of currencies) in a structured way.
 	validate.RegisterStructValidation(balanceValidator, request.Balance{})
+	validate.RegisterStructValidation(historyValidator, request.BalanceHistory{})
 	validate.RegisterStructValidation(parseValidator, request.Parse{})
 	validate.RegisterStructValidation(combineValidator, request.Combine{})
 	validate.RegisterStructValidation(submitValidator, request.Submit{})
 	validate.RegisterStructValidation(hashValidator, request.Hash{})
+	validate.RegisterStructValidation(searchValidator, request.SearchTransactions{})
 
 	return validate
 }
@@ -226,6 +230,15 @@ func balanceValidator(sl validator.StructLevel) {
 	}
 }
 
+// historyValidator ensures that the provided BalanceHistory request has the `symbol` field of its
+// currency populated.
+func historyValidator(sl validator.StructLevel) {
+	req := sl.Current().Interface().(request.BalanceHistory)
+	if req.Currency.Symbol == "" {
+		sl.ReportError(req.Currency.Symbol, symbolField, symbolField, symbolEmpty, "")
+	}
+}
+
 // parseValidator ensures that the provided Parse request has a non-empty transaction field.
 func parseValidator(sl validator.StructLevel) {
 	req := sl.Current().Interface().(request.Parse)
@@ -262,3 +275,16 @@ func hashValidator(sl validator.StructLevel) {
 		sl.ReportError(req.SignedTransaction, transactionField, transactionField, txBodyEmpty, "")
 	}
 }
+
+// searchValidator ensures that the provided SearchTransactions request does not use the `or` operator,
+// as only transactions matching all of the given filters can be searched, and that its account
+// identifier has no sub-account, as transactions are only indexed by account address.
+func searchValidator(sl validator.StructLevel) {
+	req := sl.Current().Interface().(request.SearchTransactions)
+	if req.Operator != "" && req.Operator != "and" {
+		sl.ReportError(req.Operator, operatorField, operatorField, operatorUnsupported, "")
+	}
+	if req.AccountID != nil && req.AccountID.SubAccount != nil {
+		sl.ReportError(req.AccountID.SubAccount, subAccountField, subAccountField, subAccountSearch, "")
+	}
+}
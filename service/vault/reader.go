@@ -0,0 +1,146 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package vault
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Name of the balance field of fungible token vaults.
+const balanceField = "balance"
+
+// Reader reads the balance of the token vaults of accounts directly from their
+// storage registers in the DPS index, without executing any script.
+type Reader struct {
+	index  dps.Reader
+	tokens map[string]token
+}
+
+// token contains the storage key and the type of the vault of a token.
+type token struct {
+	key   string
+	vault common.TypeID
+}
+
+// New creates a new Reader, which reads the registers at the storage paths of the
+// token vaults in the standard account setup for the given chain parameters.
+func New(params dps.Params, index dps.Reader) *Reader {
+
+	tokens := make(map[string]token, len(params.Tokens))
+	for symbol, params := range params.Tokens {
+		identifier := strings.TrimPrefix(params.Vault, "/"+common.PathDomainStorage.Identifier()+"/")
+		if identifier == params.Vault {
+			continue
+		}
+		path := interpreter.PathValue{
+			Domain:     common.PathDomainStorage,
+			Identifier: identifier,
+		}
+		location := common.AddressLocation{
+			Address: common.Address(params.Address),
+			Name:    params.Type,
+		}
+		tokens[symbol] = token{
+			key:   interpreter.StorageKey(path),
+			vault: location.TypeID(params.Type + ".Vault"),
+		}
+	}
+
+	r := Reader{
+		index:  index,
+		tokens: tokens,
+	}
+
+	return &r
+}
+
+// Balance returns the balance of the vault of the given token for the given
+// account at the given height. If the account does not have a vault for the
+// token at its standard storage path, it returns false, so that the caller can
+// fall back to a script that handles non-standard vault setups.
+func (r *Reader) Balance(height uint64, address flow.Address, symbol string) (uint64, bool, error) {
+
+	token, ok := r.tokens[symbol]
+	if !ok {
+		return 0, false, nil
+	}
+
+	// Values in account storage are stored in registers that are owned by the
+	// account, without a controller, with the storage path as key.
+	regID := flow.NewRegisterID(string(address.Bytes()), "", token.key)
+	path, err := pathfinder.KeyToPath(state.RegisterIDToKey(regID), complete.DefaultPathFinderVersion)
+	if err != nil {
+		return 0, false, fmt.Errorf("could not convert key to path: %w", err)
+	}
+
+	values, err := r.index.Values(height, []ledger.Path{path})
+	if err != nil {
+		return 0, false, fmt.Errorf("could not read register: %w", err)
+	}
+	if len(values) != 1 {
+		return 0, false, fmt.Errorf("invalid number of register values (have: %d, want: 1)", len(values))
+	}
+
+	// An empty register means that there is nothing stored at the path.
+	data, version := interpreter.StripMagic(values[0])
+	if len(data) == 0 {
+		return 0, false, nil
+	}
+
+	balance, ok := decode(address, token, data, version)
+	return balance, ok, nil
+}
+
+// decode decodes the balance of a token vault from the given stored data.
+// Cadence loads the fields of composite values lazily and panics if they can't
+// be decoded, so any value that can't be decoded as a vault of the token is
+// considered non-standard.
+func decode(address flow.Address, token token, data []byte, version uint16) (balance uint64, ok bool) {
+
+	defer func() {
+		if recover() != nil {
+			balance, ok = 0, false
+		}
+	}()
+
+	owner := common.Address(address)
+	value, err := interpreter.DecodeValue(data, &owner, []string{token.key}, version, nil)
+	if err != nil {
+		return 0, false
+	}
+
+	vault, isComposite := value.(*interpreter.CompositeValue)
+	if !isComposite || vault.TypeID() != token.vault {
+		return 0, false
+	}
+
+	amount, isUFix64 := vault.GetField(balanceField).(interpreter.UFix64Value)
+	if !isUFix64 {
+		return 0, false
+	}
+
+	return uint64(amount), true
+}
@@ -0,0 +1,237 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package vault_test
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps-rosetta/service/vault"
+	"github.com/optakt/flow-dps-rosetta/testing/mocks"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+func TestReader_Balance(t *testing.T) {
+
+	params := dps.FlowParams[dps.FlowLocalnet]
+	token := params.Tokens[dps.FlowSymbol]
+	address := mocks.GenericAccount.Address
+	height := mocks.GenericHeight
+
+	flowVault := func(balance interpreter.Value) []byte {
+		fields := interpreter.NewStringValueOrderedMap()
+		fields.Set("uuid", interpreter.UInt64Value(42))
+		if balance != nil {
+			fields.Set("balance", balance)
+		}
+		return encode(t, token.Address, token.Type, token.Type+".Vault", fields)
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(gotHeight uint64, paths []ledger.Path) ([]ledger.Value, error) {
+			assert.Equal(t, height, gotHeight)
+			assert.Len(t, paths, 1)
+
+			return []ledger.Value{flowVault(interpreter.UFix64Value(104000100000))}, nil
+		}
+
+		read := vault.New(params, index)
+
+		balance, ok, err := read.Balance(height, address, dps.FlowSymbol)
+
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(104000100000), balance)
+	})
+
+	t.Run("reads different registers for different accounts", func(t *testing.T) {
+		t.Parallel()
+
+		var requested []ledger.Path
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(_ uint64, paths []ledger.Path) ([]ledger.Value, error) {
+			requested = append(requested, paths...)
+			return []ledger.Value{flowVault(interpreter.UFix64Value(1))}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, _, err := read.Balance(height, address, dps.FlowSymbol)
+		require.NoError(t, err)
+		_, _, err = read.Balance(height, flow.HexToAddress("754aed9de6197641"), dps.FlowSymbol)
+		require.NoError(t, err)
+
+		require.Len(t, requested, 2)
+		assert.NotEqual(t, requested[0], requested[1])
+	})
+
+	t.Run("handles unknown token", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			t.Fatal("unexpected register read")
+			return nil, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, ok, err := read.Balance(height, address, "FUSD")
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles empty register", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{{}}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, ok, err := read.Balance(height, address, dps.FlowSymbol)
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles vault of other type", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			fields := interpreter.NewStringValueOrderedMap()
+			fields.Set("balance", interpreter.UFix64Value(1))
+			data := encode(t, flow.HexToAddress("f8d6e0586b0a20c7"), "CustomToken", "CustomToken.Vault", fields)
+			return []ledger.Value{data}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, ok, err := read.Balance(height, address, dps.FlowSymbol)
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles missing balance field", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{flowVault(nil)}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, ok, err := read.Balance(height, address, dps.FlowSymbol)
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles balance field of other type", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{flowVault(interpreter.UInt64Value(1))}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, ok, err := read.Balance(height, address, dps.FlowSymbol)
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles undecodable register", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			data := interpreter.PrependMagic([]byte{0xff, 0x00, 0x42}, interpreter.CurrentEncodingVersion)
+			return []ledger.Value{data}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, ok, err := read.Balance(height, address, dps.FlowSymbol)
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		read := vault.New(params, index)
+
+		_, _, err := read.Balance(height, address, dps.FlowSymbol)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid number of values", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{}, nil
+		}
+
+		read := vault.New(params, index)
+
+		_, _, err := read.Balance(height, address, dps.FlowSymbol)
+
+		assert.Error(t, err)
+	})
+}
+
+// encode encodes a resource with the given fields the way Cadence stores it in
+// account storage, for the given contract.
+func encode(t *testing.T, contract flow.Address, name string, identifier string, fields *interpreter.StringValueOrderedMap) []byte {
+	t.Helper()
+
+	location := common.AddressLocation{
+		Address: common.Address(contract),
+		Name:    name,
+	}
+	value := interpreter.NewCompositeValue(location, identifier, common.CompositeKindResource, fields, nil)
+
+	data, _, err := interpreter.EncodeValue(value, nil, false, nil)
+	require.NoError(t, err)
+
+	return interpreter.PrependMagic(data, interpreter.CurrentEncodingVersion)
+}
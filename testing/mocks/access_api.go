@@ -0,0 +1,69 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	sdk "github.com/onflow/flow-go-sdk"
+)
+
+type AccessAPI struct {
+	GetLatestBlockHeaderFunc func(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*sdk.BlockHeader, error)
+	SendTransactionFunc      func(ctx context.Context, tx sdk.Transaction, opts ...grpc.CallOption) error
+	GetTransactionResultFunc func(ctx context.Context, txID sdk.Identifier, opts ...grpc.CallOption) (*sdk.TransactionResult, error)
+}
+
+func (a *AccessAPI) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*sdk.BlockHeader, error) {
+	return a.GetLatestBlockHeaderFunc(ctx, isSealed, opts...)
+}
+
+func (a *AccessAPI) SendTransaction(ctx context.Context, tx sdk.Transaction, opts ...grpc.CallOption) error {
+	return a.SendTransactionFunc(ctx, tx, opts...)
+}
+
+func (a *AccessAPI) GetTransactionResult(ctx context.Context, txID sdk.Identifier, opts ...grpc.CallOption) (*sdk.TransactionResult, error) {
+	return a.GetTransactionResultFunc(ctx, txID, opts...)
+}
+
+func BaselineAccessAPI(t *testing.T) *AccessAPI {
+	t.Helper()
+
+	a := AccessAPI{
+		GetLatestBlockHeaderFunc: func(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*sdk.BlockHeader, error) {
+			header := sdk.BlockHeader{
+				ID:        sdk.Identifier(GenericHeader.ID()),
+				ParentID:  sdk.Identifier(GenericHeader.ParentID),
+				Height:    GenericHeader.Height,
+				Timestamp: GenericHeader.Timestamp,
+			}
+			return &header, nil
+		},
+		SendTransactionFunc: func(ctx context.Context, tx sdk.Transaction, opts ...grpc.CallOption) error {
+			return nil
+		},
+		GetTransactionResultFunc: func(ctx context.Context, txID sdk.Identifier, opts ...grpc.CallOption) (*sdk.TransactionResult, error) {
+			result := sdk.TransactionResult{
+				Status: sdk.TransactionStatusPending,
+			}
+			return &result, nil
+		},
+	}
+
+	return &a
+}
@@ -18,9 +18,27 @@ import "testing"
 
 type Generator struct {
 	GetBalanceFunc      func(symbol string) ([]byte, error)
+	GetBalancesFunc     func(symbols []string) ([]byte, error)
 	TokensDepositedFunc func(symbol string) (string, error)
 	TokensWithdrawnFunc func(symbol string) (string, error)
+	TokensMintedFunc    func(symbol string) (string, error)
+	TokensBurnedFunc    func(symbol string) (string, error)
 	TransferTokensFunc  func(symbol string) ([]byte, error)
+	FeesDeductedFunc    func() (string, error)
+	AccountCreatedFunc  func() (string, error)
+
+	TokensCommittedFunc          func() (string, error)
+	TokensUnstakedFunc           func() (string, error)
+	RewardsPaidFunc              func() (string, error)
+	DelegatorTokensCommittedFunc func() (string, error)
+	DelegatorTokensUnstakedFunc  func() (string, error)
+	DelegatorRewardsPaidFunc     func() (string, error)
+
+	GetLockedBalanceFunc    func() ([]byte, error)
+	GetStakedBalanceFunc    func() ([]byte, error)
+	GetDelegatedBalanceFunc func() ([]byte, error)
+	GetStorageReservedFunc  func() ([]byte, error)
+	GetStakedNodesFunc      func() ([]byte, error)
 }
 
 func BaselineGenerator(t *testing.T) *Generator {
@@ -30,15 +48,63 @@ func BaselineGenerator(t *testing.T) *Generator {
 		GetBalanceFunc: func(string) ([]byte, error) {
 			return []byte(GenericAmount(0).String()), nil
 		},
+		GetBalancesFunc: func([]string) ([]byte, error) {
+			return GenericBytes, nil
+		},
 		TokensDepositedFunc: func(string) (string, error) {
 			return string(GenericEventType(0)), nil
 		},
 		TokensWithdrawnFunc: func(string) (string, error) {
 			return string(GenericEventType(1)), nil
 		},
+		TokensMintedFunc: func(string) (string, error) {
+			return string(GenericEventType(10)), nil
+		},
+		TokensBurnedFunc: func(string) (string, error) {
+			return string(GenericEventType(11)), nil
+		},
 		TransferTokensFunc: func(string) ([]byte, error) {
 			return GenericBytes, nil
 		},
+		FeesDeductedFunc: func() (string, error) {
+			return string(GenericEventType(2)), nil
+		},
+		AccountCreatedFunc: func() (string, error) {
+			return string(GenericEventType(9)), nil
+		},
+		TokensCommittedFunc: func() (string, error) {
+			return string(GenericEventType(3)), nil
+		},
+		TokensUnstakedFunc: func() (string, error) {
+			return string(GenericEventType(4)), nil
+		},
+		RewardsPaidFunc: func() (string, error) {
+			return string(GenericEventType(5)), nil
+		},
+		DelegatorTokensCommittedFunc: func() (string, error) {
+			return string(GenericEventType(6)), nil
+		},
+		DelegatorTokensUnstakedFunc: func() (string, error) {
+			return string(GenericEventType(7)), nil
+		},
+		DelegatorRewardsPaidFunc: func() (string, error) {
+			return string(GenericEventType(8)), nil
+		},
+		GetLockedBalanceFunc: func() ([]byte, error) {
+			return []byte(GenericAmount(1).String()), nil
+		},
+		GetStakedBalanceFunc: func() ([]byte, error) {
+			return []byte(GenericAmount(2).String()), nil
+		},
+		GetDelegatedBalanceFunc: func() ([]byte, error) {
+			return []byte(GenericAmount(3).String()), nil
+		},
+		GetStorageReservedFunc: func() ([]byte, error) {
+			return []byte(GenericAmount(4).String()), nil
+		},
+		GetStakedNodesFunc: func() ([]byte, error) {
+			return GenericBytes, nil
+		},
 	}
 
 	return &g
@@ -48,6 +114,10 @@ func (g *Generator) GetBalance(symbol string) ([]byte, error) {
 	return g.GetBalanceFunc(symbol)
 }
 
+func (g *Generator) GetBalances(symbols []string) ([]byte, error) {
+	return g.GetBalancesFunc(symbols)
+}
+
 func (g *Generator) TokensDeposited(symbol string) (string, error) {
 	return g.TokensDepositedFunc(symbol)
 }
@@ -56,6 +126,66 @@ func (g *Generator) TokensWithdrawn(symbol string) (string, error) {
 	return g.TokensWithdrawnFunc(symbol)
 }
 
+func (g *Generator) TokensMinted(symbol string) (string, error) {
+	return g.TokensMintedFunc(symbol)
+}
+
+func (g *Generator) TokensBurned(symbol string) (string, error) {
+	return g.TokensBurnedFunc(symbol)
+}
+
 func (g *Generator) TransferTokens(symbol string) ([]byte, error) {
 	return g.TransferTokensFunc(symbol)
 }
+
+func (g *Generator) FeesDeducted() (string, error) {
+	return g.FeesDeductedFunc()
+}
+
+func (g *Generator) AccountCreated() (string, error) {
+	return g.AccountCreatedFunc()
+}
+
+func (g *Generator) TokensCommitted() (string, error) {
+	return g.TokensCommittedFunc()
+}
+
+func (g *Generator) TokensUnstaked() (string, error) {
+	return g.TokensUnstakedFunc()
+}
+
+func (g *Generator) RewardsPaid() (string, error) {
+	return g.RewardsPaidFunc()
+}
+
+func (g *Generator) DelegatorTokensCommitted() (string, error) {
+	return g.DelegatorTokensCommittedFunc()
+}
+
+func (g *Generator) DelegatorTokensUnstaked() (string, error) {
+	return g.DelegatorTokensUnstakedFunc()
+}
+
+func (g *Generator) DelegatorRewardsPaid() (string, error) {
+	return g.DelegatorRewardsPaidFunc()
+}
+
+func (g *Generator) GetLockedBalance() ([]byte, error) {
+	return g.GetLockedBalanceFunc()
+}
+
+func (g *Generator) GetStakedBalance() ([]byte, error) {
+	return g.GetStakedBalanceFunc()
+}
+
+func (g *Generator) GetDelegatedBalance() ([]byte, error) {
+	return g.GetDelegatedBalanceFunc()
+}
+
+func (g *Generator) GetStorageReserved() ([]byte, error) {
+	return g.GetStorageReservedFunc()
+}
+
+func (g *Generator) GetStakedNodes() ([]byte, error) {
+	return g.GetStakedNodesFunc()
+}
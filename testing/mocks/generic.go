@@ -134,7 +134,12 @@ var (
 		Hash:  GenericHeader.ID().String(),
 	}
 
-	GenericParams = dps.Params{ChainID: dps.FlowTestnet}
+	GenericParams = dps.Params{
+		ChainID: dps.FlowTestnet,
+		Tokens: map[string]dps.Token{
+			dps.FlowSymbol: {Symbol: dps.FlowSymbol},
+		},
+	}
 )
 
 func GenericBlockIDs(number int) []flow.Identifier {
@@ -421,7 +426,7 @@ func GenericOperations(number int) []object.Operation {
 			Type:      dps.OperationTransfer,
 			Status:    dps.StatusCompleted,
 			AccountID: account,
-			Amount: object.Amount{
+			Amount: &object.Amount{
 				Value:    value,
 				Currency: GenericCurrency,
 			},
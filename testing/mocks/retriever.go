@@ -0,0 +1,53 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"testing"
+
+	"github.com/optakt/flow-dps-rosetta/service/identifier"
+	"github.com/optakt/flow-dps-rosetta/service/object"
+)
+
+type Retriever struct {
+	BlockTransactionsFunc func(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error)
+	TransactionFunc       func(rosBlockID identifier.Block, rosTxID identifier.Transaction) (*object.Transaction, error)
+}
+
+func BaselineRetriever(t *testing.T) *Retriever {
+	t.Helper()
+
+	r := Retriever{
+		BlockTransactionsFunc: func(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error) {
+			return GenericRosBlockID, []*object.Transaction{}, 0, nil
+		},
+		TransactionFunc: func(rosBlockID identifier.Block, rosTxID identifier.Transaction) (*object.Transaction, error) {
+			transaction := object.Transaction{
+				ID: rosTxID,
+			}
+			return &transaction, nil
+		},
+	}
+
+	return &r
+}
+
+func (r *Retriever) BlockTransactions(rosBlockID identifier.Block, offset uint, limit uint) (identifier.Block, []*object.Transaction, uint, error) {
+	return r.BlockTransactionsFunc(rosBlockID, offset, limit)
+}
+
+func (r *Retriever) Transaction(rosBlockID identifier.Block, rosTxID identifier.Transaction) (*object.Transaction, error) {
+	return r.TransactionFunc(rosBlockID, rosTxID)
+}
@@ -22,12 +22,22 @@ import (
 
 type Submitter struct {
 	TransactionFunc func(tx *sdk.Transaction) error
+	PendingFunc     func() ([]*sdk.Transaction, error)
+	LookupFunc      func(txID sdk.Identifier) (*sdk.Transaction, bool, error)
 }
 
 func (s *Submitter) Transaction(tx *sdk.Transaction) error {
 	return s.TransactionFunc(tx)
 }
 
+func (s *Submitter) Pending() ([]*sdk.Transaction, error) {
+	return s.PendingFunc()
+}
+
+func (s *Submitter) Lookup(txID sdk.Identifier) (*sdk.Transaction, bool, error) {
+	return s.LookupFunc(txID)
+}
+
 func BaselineSubmitter(t *testing.T) *Submitter {
 	t.Helper()
 
@@ -35,6 +45,12 @@ func BaselineSubmitter(t *testing.T) *Submitter {
 		TransactionFunc: func(tx *sdk.Transaction) error {
 			return nil
 		},
+		PendingFunc: func() ([]*sdk.Transaction, error) {
+			return []*sdk.Transaction{}, nil
+		},
+		LookupFunc: func(sdk.Identifier) (*sdk.Transaction, bool, error) {
+			return nil, false, nil
+		},
 	}
 
 	return &s
@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+type Vault struct {
+	BalanceFunc func(height uint64, address flow.Address, symbol string) (uint64, bool, error)
+}
+
+func BaselineVault(t *testing.T) *Vault {
+	t.Helper()
+
+	v := Vault{
+		BalanceFunc: func(uint64, flow.Address, string) (uint64, bool, error) {
+			return 0, false, nil
+		},
+	}
+
+	return &v
+}
+
+func (v *Vault) Balance(height uint64, address flow.Address, symbol string) (uint64, bool, error) {
+	return v.BalanceFunc(height, address, symbol)
+}